@@ -0,0 +1,365 @@
+/*
+Package archive implements append-only archive of the blocks of a shard.
+
+The archive is a directory of segment files, each segment file is accompanied
+by an index file:
+
+	00000000.seg, 00000000.idx, 00000001.seg, 00000001.idx, ...
+
+Segment starts with a header (magic, format version, length of the header data
+and CBOR encoded partition and shard ID) followed by the records. Record is
+
+	length of the block (uint32) | checksum (uint32) | round (uint64) | CBOR encoded block
+
+where the checksum is CRC-32C of the round and block bytes. When segment reaches
+the size limit it is sealed by appending a trailer
+
+	0xFFFFFFFF | checksum of the segment (uint32) | record count (uint64)
+
+where the checksum is CRC-32C of all the bytes of the segment preceding the
+trailer. Only the last segment of the archive may be unsealed.
+
+Index file is a sequence of (round, offset) pairs (both uint64), one pair for
+each record of the segment. Index can always be rebuilt from the segment file.
+All integers are big-endian.
+*/
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+const (
+	segmentMagic  = "ABAR"
+	formatVersion = 1
+
+	segmentExt = ".seg"
+	indexExt   = ".idx"
+
+	recordHeaderSize = 16 // length, checksum, round
+	indexEntrySize   = 16 // round, offset
+	trailerMarker    = math.MaxUint32
+	maxRecordSize    = 1 << 30
+
+	// DefaultMaxSegmentSize is the size after which the writer starts a new segment.
+	DefaultMaxSegmentSize = 256 << 20
+)
+
+var (
+	ErrNotFound  = errors.New("block not found")
+	ErrCorrupted = errors.New("archive is corrupted")
+	ErrClosed    = errors.New("archive is closed")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+/*
+Header describes the shard whose blocks are stored in the archive, it is
+stored at the beginning of each segment.
+*/
+type Header struct {
+	_           struct{} `cbor:",toarray"`
+	PartitionID types.PartitionID
+	ShardID     types.ShardID
+}
+
+func (h *Header) equal(other *Header) bool {
+	return h.PartitionID == other.PartitionID && h.ShardID.Equal(other.ShardID)
+}
+
+type indexEntry struct {
+	round  uint64
+	offset int64
+}
+
+func segmentPath(dir string, seq uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%08d%s", seq, segmentExt))
+}
+
+func indexPath(dir string, seq uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%08d%s", seq, indexExt))
+}
+
+// listSegments returns the sequence numbers of the segments in the "dir" in ascending order.
+func listSegments(dir string) ([]uint32, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint32
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), segmentExt)
+		if !ok || f.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, uint32(seq))
+	}
+	slices.Sort(seqs)
+	for i, seq := range seqs {
+		if seq != uint32(i) {
+			return nil, fmt.Errorf("%w: segment %d is missing", ErrCorrupted, i)
+		}
+	}
+	return seqs, nil
+}
+
+func encodeHeader(h *Header) ([]byte, error) {
+	data, err := types.Cbor.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("encoding header: %w", err)
+	}
+	if len(data) > math.MaxUint16 {
+		return nil, fmt.Errorf("header is too big: %d bytes", len(data))
+	}
+	buf := make([]byte, 0, len(segmentMagic)+3+len(data))
+	buf = append(buf, segmentMagic...)
+	buf = append(buf, formatVersion)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+	return append(buf, data...), nil
+}
+
+// readHeader reads the segment header and returns it together with it's raw bytes.
+func readHeader(r io.Reader) (*Header, []byte, error) {
+	prefix := make([]byte, len(segmentMagic)+3)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, nil, fmt.Errorf("reading segment header: %w", err)
+	}
+	if string(prefix[:len(segmentMagic)]) != segmentMagic {
+		return nil, nil, fmt.Errorf("%w: not an archive segment", ErrCorrupted)
+	}
+	if v := prefix[len(segmentMagic)]; v != formatVersion {
+		return nil, nil, fmt.Errorf("unsupported archive format version %d", v)
+	}
+	data := make([]byte, binary.BigEndian.Uint16(prefix[len(segmentMagic)+1:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, nil, fmt.Errorf("reading segment header: %w", err)
+	}
+	h := &Header{}
+	if err := types.Cbor.Unmarshal(data, h); err != nil {
+		return nil, nil, fmt.Errorf("%w: decoding segment header: %w", ErrCorrupted, err)
+	}
+	return h, append(prefix, data...), nil
+}
+
+func encodeRecord(round uint64, block []byte) []byte {
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(block))
+	binary.BigEndian.PutUint32(buf, uint32(len(block)))
+	binary.BigEndian.PutUint64(buf[8:], round)
+	buf = append(buf, block...)
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(buf[8:], crcTable))
+	return buf
+}
+
+func encodeTrailer(checksum uint32, count uint64) []byte {
+	buf := make([]byte, recordHeaderSize)
+	binary.BigEndian.PutUint32(buf, trailerMarker)
+	binary.BigEndian.PutUint32(buf[4:], checksum)
+	binary.BigEndian.PutUint64(buf[8:], count)
+	return buf
+}
+
+/*
+readRecord reads the record at "offset" of the segment and checks it's
+checksum, the round and CBOR encoded block are returned.
+*/
+func readRecord(f io.ReaderAt, offset int64) (uint64, []byte, error) {
+	hdr := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(hdr, offset); err != nil {
+		return 0, nil, fmt.Errorf("reading record header: %w", err)
+	}
+	length := binary.BigEndian.Uint32(hdr)
+	if length == trailerMarker || length > maxRecordSize {
+		return 0, nil, fmt.Errorf("%w: invalid record length %d at offset %d", ErrCorrupted, length, offset)
+	}
+	buf := make([]byte, 8+length)
+	copy(buf, hdr[8:])
+	if _, err := f.ReadAt(buf[8:], offset+recordHeaderSize); err != nil {
+		return 0, nil, fmt.Errorf("reading record: %w", err)
+	}
+	if crc32.Checksum(buf, crcTable) != binary.BigEndian.Uint32(hdr[4:]) {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch of the record at offset %d", ErrCorrupted, offset)
+	}
+	return binary.BigEndian.Uint64(hdr[8:]), buf[8:], nil
+}
+
+func decodeBlock(data []byte) (*types.Block, error) {
+	b := &types.Block{}
+	if err := types.Cbor.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("decoding block: %w", err)
+	}
+	return b, nil
+}
+
+/*
+segmentScan is the result of reading the segment record by record.
+*/
+type segmentScan struct {
+	header   *Header
+	entries  []indexEntry
+	end      int64  // offset of the end of the last valid record or trailer
+	checksum uint32 // checksum of the header and valid records
+	sealed   bool
+	// err is the reason scanning stopped before the end of the segment,
+	// nil when the whole segment is valid
+	err error
+}
+
+func (s *segmentScan) lastRound() uint64 {
+	if len(s.entries) == 0 {
+		return 0
+	}
+	return s.entries[len(s.entries)-1].round
+}
+
+/*
+scanSegment reads the segment and checks the checksums of the records and the
+trailer. Error is returned only when the header of the segment can't be read,
+inconsistencies in the records are reported by segmentScan.err.
+*/
+func scanSegment(r io.Reader) (*segmentScan, error) {
+	br := bufio.NewReader(r)
+	h, hdrBytes, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	scan := &segmentScan{
+		header:   h,
+		end:      int64(len(hdrBytes)),
+		checksum: crc32.Checksum(hdrBytes, crcTable),
+	}
+
+	hdr := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			if err != io.EOF {
+				scan.err = fmt.Errorf("torn record header at offset %d: %w", scan.end, err)
+			}
+			return scan, nil
+		}
+
+		length := binary.BigEndian.Uint32(hdr)
+		if length == trailerMarker {
+			checksum, count := binary.BigEndian.Uint32(hdr[4:]), binary.BigEndian.Uint64(hdr[8:])
+			switch {
+			case checksum != scan.checksum:
+				scan.err = fmt.Errorf("%w: segment checksum %08X does not match the checksum in the trailer %08X", ErrCorrupted, scan.checksum, checksum)
+			case count != uint64(len(scan.entries)):
+				scan.err = fmt.Errorf("%w: segment has %d records, trailer says %d", ErrCorrupted, len(scan.entries), count)
+			default:
+				if n, _ := br.Discard(1); n != 0 {
+					scan.err = fmt.Errorf("%w: data after the segment trailer", ErrCorrupted)
+				}
+				scan.sealed = true
+				scan.end += recordHeaderSize
+			}
+			return scan, nil
+		}
+		if length > maxRecordSize {
+			scan.err = fmt.Errorf("%w: invalid record length %d at offset %d", ErrCorrupted, length, scan.end)
+			return scan, nil
+		}
+
+		data := make([]byte, 8+length)
+		copy(data, hdr[8:])
+		if _, err := io.ReadFull(br, data[8:]); err != nil {
+			scan.err = fmt.Errorf("torn record at offset %d: %w", scan.end, err)
+			return scan, nil
+		}
+		if crc32.Checksum(data, crcTable) != binary.BigEndian.Uint32(hdr[4:]) {
+			scan.err = fmt.Errorf("%w: checksum mismatch of the record at offset %d", ErrCorrupted, scan.end)
+			return scan, nil
+		}
+		round := binary.BigEndian.Uint64(hdr[8:])
+		if last := scan.lastRound(); len(scan.entries) > 0 && round <= last {
+			scan.err = fmt.Errorf("%w: record of round %d follows the record of round %d", ErrCorrupted, round, last)
+			return scan, nil
+		}
+
+		scan.entries = append(scan.entries, indexEntry{round: round, offset: scan.end})
+		scan.checksum = crc32.Update(scan.checksum, crcTable, hdr)
+		scan.checksum = crc32.Update(scan.checksum, crcTable, data[8:])
+		scan.end += int64(recordHeaderSize) + int64(length)
+	}
+}
+
+func encodeIndex(entries []indexEntry) []byte {
+	buf := make([]byte, 0, len(entries)*indexEntrySize)
+	for _, e := range entries {
+		buf = binary.BigEndian.AppendUint64(buf, e.round)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.offset))
+	}
+	return buf
+}
+
+/*
+decodeIndex decodes the index file content, incomplete last entry (torn write)
+is ignored.
+*/
+func decodeIndex(data []byte) ([]indexEntry, error) {
+	entries := make([]indexEntry, 0, len(data)/indexEntrySize)
+	for ; len(data) >= indexEntrySize; data = data[indexEntrySize:] {
+		e := indexEntry{
+			round:  binary.BigEndian.Uint64(data),
+			offset: int64(binary.BigEndian.Uint64(data[8:])),
+		}
+		if n := len(entries); n > 0 && e.round <= entries[n-1].round {
+			return nil, fmt.Errorf("%w: index entry of round %d follows the entry of round %d", ErrCorrupted, e.round, entries[n-1].round)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func readIndex(dir string, seq uint32) ([]indexEntry, error) {
+	// #nosec G304
+	data, err := os.ReadFile(indexPath(dir, seq))
+	if err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+	return decodeIndex(data)
+}
+
+func writeIndex(dir string, seq uint32, entries []indexEntry) error {
+	return writeFileSync(indexPath(dir, seq), encodeIndex(entries))
+}
+
+func writeFileSync(path string, data []byte) error {
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// headerOf returns the header of the segment file.
+func headerOf(path string) (*Header, error) {
+	// #nosec G304
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h, _, err := readHeader(f)
+	return h, err
+}
@@ -0,0 +1,264 @@
+package archive
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+/*
+Reader provides random access to the blocks of the archive. Reader sees the
+blocks which were in the archive when it was opened. Reader is safe for
+concurrent use, reads after Close return ErrClosed.
+*/
+type Reader struct {
+	dir    string
+	header Header
+
+	mu       sync.RWMutex // guards closing of the segment files against reads
+	segments []*readerSegment
+	closed   bool
+}
+
+type readerSegment struct {
+	seq     uint32
+	f       *os.File
+	entries []indexEntry
+}
+
+func (s *readerSegment) firstRound() uint64 { return s.entries[0].round }
+
+func (s *readerSegment) lastRound() uint64 { return s.entries[len(s.entries)-1].round }
+
+/*
+OpenReader opens the archive in the "dir" for reading. The archive is not
+recovered, records not in the index (ie torn writes) are not visible to the
+reader.
+*/
+func OpenReader(dir string) (*Reader, error) {
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing segments: %w", err)
+	}
+	if len(seqs) == 0 {
+		return nil, fmt.Errorf("no archive segments found in %q", dir)
+	}
+
+	r := &Reader{dir: dir}
+	for _, seq := range seqs {
+		if err := r.openSegment(seq); err != nil {
+			return nil, errors.Join(fmt.Errorf("opening segment %d: %w", seq, err), r.Close())
+		}
+	}
+	return r, nil
+}
+
+func (r *Reader) openSegment(seq uint32) error {
+	// #nosec G304
+	f, err := os.Open(segmentPath(r.dir, seq))
+	if err != nil {
+		return err
+	}
+	h, _, err := readHeader(f)
+	if err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if seq == 0 {
+		r.header = *h
+	} else if !h.equal(&r.header) {
+		return errors.Join(fmt.Errorf("%w: segment is of partition %s shard %q, archive is of partition %s shard %q",
+			ErrCorrupted, h.PartitionID, h.ShardID, r.header.PartitionID, r.header.ShardID), f.Close())
+	}
+
+	entries, err := readIndex(r.dir, seq)
+	if err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if len(entries) == 0 {
+		// empty (last) segment, no need to keep it open
+		return f.Close()
+	}
+	if n := len(r.segments); n > 0 && entries[0].round <= r.segments[n-1].lastRound() {
+		return errors.Join(fmt.Errorf("%w: segment starts with round %d, previous segment ends with round %d",
+			ErrCorrupted, entries[0].round, r.segments[n-1].lastRound()), f.Close())
+	}
+	r.segments = append(r.segments, &readerSegment{seq: seq, f: f, entries: entries})
+	return nil
+}
+
+// Header returns the header of the archive, ie the partition and shard ID of the blocks.
+func (r *Reader) Header() Header { return r.header }
+
+// FirstRound returns the round of the first block in the archive, zero when the archive is empty.
+func (r *Reader) FirstRound() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.segments) == 0 {
+		return 0
+	}
+	return r.segments[0].firstRound()
+}
+
+// LastRound returns the round of the last block in the archive, zero when the archive is empty.
+func (r *Reader) LastRound() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.segments) == 0 {
+		return 0
+	}
+	return r.segments[len(r.segments)-1].lastRound()
+}
+
+/*
+Block returns the block of the given round, ErrNotFound is returned when the
+archive doesn't contain the block of the round.
+*/
+func (r *Reader) Block(round uint64) (*types.Block, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+	si, found := slices.BinarySearchFunc(r.segments, round, func(s *readerSegment, round uint64) int {
+		switch {
+		case s.lastRound() < round:
+			return -1
+		case s.firstRound() > round:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if !found {
+		return nil, fmt.Errorf("%w: round %d", ErrNotFound, round)
+	}
+	seg := r.segments[si]
+	ei, found := slices.BinarySearchFunc(seg.entries, round, func(e indexEntry, round uint64) int {
+		return cmp.Compare(e.round, round)
+	})
+	if !found {
+		return nil, fmt.Errorf("%w: round %d", ErrNotFound, round)
+	}
+	return seg.readBlock(seg.entries[ei])
+}
+
+/*
+All returns iterator over the blocks starting from the block of round "from"
+(or the first block following it when the archive doesn't contain the block
+of the round). Iteration stops after the first error.
+*/
+func (r *Reader) All(from uint64) iter.Seq2[*types.Block, error] {
+	return func(yield func(*types.Block, error) bool) {
+		r.mu.RLock()
+		segments, closed := r.segments, r.closed
+		r.mu.RUnlock()
+		if closed {
+			yield(nil, ErrClosed)
+			return
+		}
+		for _, seg := range segments {
+			if seg.lastRound() < from {
+				continue
+			}
+			start, _ := slices.BinarySearchFunc(seg.entries, from, func(e indexEntry, round uint64) int {
+				return cmp.Compare(e.round, round)
+			})
+			for _, e := range seg.entries[start:] {
+				b, err := r.readBlock(seg, e)
+				if !yield(b, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+/*
+Verify reads all the segments of the archive and checks the checksums of the
+records and sealed segments and that the index matches the records.
+*/
+func (r *Reader) Verify() error {
+	seqs, err := listSegments(r.dir)
+	if err != nil {
+		return fmt.Errorf("listing segments: %w", err)
+	}
+	for i, seq := range seqs {
+		if err := verifySegment(r.dir, seq, i == len(seqs)-1); err != nil {
+			return fmt.Errorf("segment %d: %w", seq, err)
+		}
+	}
+	return nil
+}
+
+func verifySegment(dir string, seq uint32, last bool) error {
+	// #nosec G304
+	f, err := os.Open(segmentPath(dir, seq))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scan, err := scanSegment(f)
+	if err != nil {
+		return err
+	}
+	if scan.err != nil {
+		return scan.err
+	}
+	if !last && !scan.sealed {
+		return fmt.Errorf("%w: segment is not sealed", ErrCorrupted)
+	}
+	entries, err := readIndex(dir, seq)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(entries, scan.entries) {
+		return fmt.Errorf("%w: index doesn't match the segment", ErrCorrupted)
+	}
+	return nil
+}
+
+// Close closes the segment files of the archive.
+func (r *Reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	var errs []error
+	for _, s := range r.segments {
+		errs = append(errs, s.f.Close())
+	}
+	r.segments, r.closed = nil, true
+	return errors.Join(errs...)
+}
+
+// readBlock reads the block of the segment, the lock is not held while the caller consumes the block.
+func (r *Reader) readBlock(s *readerSegment, e indexEntry) (*types.Block, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+	return s.readBlock(e)
+}
+
+func (s *readerSegment) readBlock(e indexEntry) (*types.Block, error) {
+	round, data, err := readRecord(s.f, e.offset)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("%w: %w", ErrCorrupted, err)
+		}
+		return nil, fmt.Errorf("reading block of round %d from segment %d: %w", e.round, s.seq, err)
+	}
+	if round != e.round {
+		return nil, fmt.Errorf("%w: index entry of round %d points to the record of round %d", ErrCorrupted, e.round, round)
+	}
+	return decodeBlock(data)
+}
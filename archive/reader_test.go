@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+func TestReader(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+	require.NoError(t, err)
+	writeBlocks(t, w, 2, 3, 5, 6, 7, 9, 10, 12)
+	require.NoError(t, w.Close())
+
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Equal(t, testPartitionID, r.Header().PartitionID)
+	require.EqualValues(t, 2, r.FirstRound())
+	require.EqualValues(t, 12, r.LastRound())
+
+	t.Run("block by round", func(t *testing.T) {
+		b, err := r.Block(7)
+		require.NoError(t, err)
+		round, err := b.GetRoundNumber()
+		require.NoError(t, err)
+		require.EqualValues(t, 7, round)
+
+		for _, round := range []uint64{0, 1, 4, 8, 11, 13} {
+			_, err := r.Block(round)
+			require.ErrorIs(t, err, ErrNotFound, "round %d", round)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		collect := func(from uint64) (rounds []uint64) {
+			for b, err := range r.All(from) {
+				require.NoError(t, err)
+				round, err := b.GetRoundNumber()
+				require.NoError(t, err)
+				rounds = append(rounds, round)
+			}
+			return rounds
+		}
+		require.Equal(t, []uint64{2, 3, 5, 6, 7, 9, 10, 12}, collect(0))
+		require.Equal(t, []uint64{5, 6, 7, 9, 10, 12}, collect(4))
+		require.Equal(t, []uint64{12}, collect(12))
+		require.Empty(t, collect(13))
+
+		// stop early
+		cnt := 0
+		for range r.All(0) {
+			if cnt++; cnt == 2 {
+				break
+			}
+		}
+		require.Equal(t, 2, cnt)
+	})
+
+	t.Run("no archive", func(t *testing.T) {
+		_, err := OpenReader(t.TempDir())
+		require.ErrorContains(t, err, "no archive segments found")
+	})
+}
+
+func TestReader_Close(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+	require.NoError(t, err)
+	writeBlocks(t, w, 1, 2, 3, 4, 5, 6)
+	require.NoError(t, w.Close())
+
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	// closing while iterating
+	for b, err := range r.All(0) {
+		if b == nil {
+			require.ErrorIs(t, err, ErrClosed)
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+	}
+	require.ErrorIs(t, r.Close(), ErrClosed)
+	_, err = r.Block(1)
+	require.ErrorIs(t, err, ErrClosed)
+	for _, err := range r.All(0) {
+		require.ErrorIs(t, err, ErrClosed)
+	}
+}
+
+func TestReader_Verify(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+	require.NoError(t, err)
+	writeBlocks(t, w, 1, 2, 3, 4, 5, 6)
+	require.NoError(t, w.Close())
+
+	// flip a byte in the block data of the first segment
+	data, err := os.ReadFile(segmentPath(dir, 0))
+	require.NoError(t, err)
+	data[len(data)-recordHeaderSize-1] ^= 0xFF
+	require.NoError(t, os.WriteFile(segmentPath(dir, 0), data, 0600))
+
+	r, err := OpenReader(dir)
+	require.NoError(t, err)
+	defer r.Close()
+	require.ErrorIs(t, r.Verify(), ErrCorrupted)
+
+	// reading the damaged block fails, others are readable
+	var lastErr error
+	cnt := 0
+	for _, err := range r.All(0) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		cnt++
+	}
+	require.ErrorIs(t, lastErr, ErrCorrupted)
+	require.Less(t, cnt, 6)
+}
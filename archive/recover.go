@@ -0,0 +1,119 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+/*
+Recover brings the archive in the "dir" into consistent state after a crash:
+  - record torn by interrupted write at the end of the last segment is
+    truncated, last segment with incomplete header is removed;
+  - index of the last segment and missing or damaged index of any other
+    segment is rebuilt from the segment file.
+
+Returns the number of bytes removed from the segment files. Corruption which
+can't be fixed by truncating the last segment is reported as ErrCorrupted.
+*/
+func Recover(dir string) (truncated int64, _ error) {
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return 0, fmt.Errorf("listing segments: %w", err)
+	}
+	for i, seq := range seqs {
+		last := i == len(seqs)-1
+		if !last && indexIsIntact(dir, seq) {
+			continue
+		}
+		n, err := recoverSegment(dir, seq, last)
+		if err != nil {
+			return truncated, fmt.Errorf("recovering segment %d: %w", seq, err)
+		}
+		truncated += n
+	}
+	return truncated, nil
+}
+
+func indexIsIntact(dir string, seq uint32) bool {
+	fi, err := os.Stat(indexPath(dir, seq))
+	return err == nil && fi.Size() > 0 && fi.Size()%indexEntrySize == 0
+}
+
+/*
+recoverSegment truncates the torn tail of the last segment and rebuilds the
+index of the segment. Segments other than the last one must be sealed and valid.
+*/
+func recoverSegment(dir string, seq uint32, last bool) (int64, error) {
+	// #nosec G304
+	f, err := os.OpenFile(segmentPath(dir, seq), os.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+	scan, truncated, err := repairSegment(f, last)
+	if err := errors.Join(err, f.Close()); err != nil {
+		return 0, err
+	}
+
+	if scan == nil {
+		// segment was created but writing the header didn't complete
+		if err := os.Remove(segmentPath(dir, seq)); err != nil {
+			return 0, err
+		}
+		if err := os.Remove(indexPath(dir, seq)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+		return truncated, nil
+	}
+	if err := writeIndex(dir, seq, scan.entries); err != nil {
+		return 0, fmt.Errorf("writing index: %w", err)
+	}
+	return truncated, nil
+}
+
+/*
+repairSegment scans the segment and truncates the torn tail (incomplete last
+record) of the last segment. Invalid complete records are not truncated but
+reported as ErrCorrupted as the records following them would be lost too.
+When the header of the last segment is torn nil scan is returned, ie the whole
+segment must be removed.
+*/
+func repairSegment(f *os.File, last bool) (*segmentScan, int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	scan, err := scanSegment(f)
+	if err != nil {
+		if last && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return nil, fi.Size(), nil
+		}
+		return nil, 0, err
+	}
+
+	if scan.err != nil {
+		if errors.Is(scan.err, ErrCorrupted) {
+			return nil, 0, scan.err
+		}
+		if !last {
+			return nil, 0, fmt.Errorf("%w: %w", ErrCorrupted, scan.err)
+		}
+	}
+	if !last && !scan.sealed {
+		return nil, 0, fmt.Errorf("%w: segment is not sealed", ErrCorrupted)
+	}
+
+	var truncated int64
+	if scan.end < fi.Size() {
+		if err := f.Truncate(scan.end); err != nil {
+			return nil, 0, fmt.Errorf("truncating segment: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return nil, 0, fmt.Errorf("syncing segment: %w", err)
+		}
+		truncated = fi.Size() - scan.end
+	}
+	return scan, truncated, nil
+}
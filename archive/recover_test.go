@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+func TestRecover(t *testing.T) {
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+		require.NoError(t, err)
+		writeBlocks(t, w, 1, 2, 3, 4, 5, 6)
+		require.NoError(t, w.Close())
+		return dir
+	}
+	lastSegment := func(t *testing.T, dir string) uint32 {
+		seqs, err := listSegments(dir)
+		require.NoError(t, err)
+		return seqs[len(seqs)-1]
+	}
+	rounds := func(t *testing.T, dir string) (res []uint64) {
+		r, err := OpenReader(dir)
+		require.NoError(t, err)
+		defer r.Close()
+		require.NoError(t, r.Verify())
+		for b, err := range r.All(0) {
+			require.NoError(t, err)
+			round, err := b.GetRoundNumber()
+			require.NoError(t, err)
+			res = append(res, round)
+		}
+		return res
+	}
+
+	t.Run("consistent archive", func(t *testing.T) {
+		dir := setup(t)
+		n, err := Recover(dir)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, rounds(t, dir))
+	})
+
+	t.Run("torn record", func(t *testing.T) {
+		dir := setup(t)
+		seq := lastSegment(t, dir)
+		// simulate partially written record and index entry
+		f, err := os.OpenFile(segmentPath(dir, seq), os.O_WRONLY|os.O_APPEND, 0600)
+		require.NoError(t, err)
+		_, err = f.Write(encodeRecord(7, []byte{1, 2, 3, 4, 5, 6})[:10])
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		f, err = os.OpenFile(indexPath(dir, seq), os.O_WRONLY|os.O_APPEND, 0600)
+		require.NoError(t, err)
+		_, err = f.Write([]byte{0, 0, 0})
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		n, err := Recover(dir)
+		require.NoError(t, err)
+		require.EqualValues(t, 10, n)
+		require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, rounds(t, dir))
+
+		// archive can be appended after recovery
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		writeBlocks(t, w, 7)
+		require.NoError(t, w.Close())
+		require.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7}, rounds(t, dir))
+	})
+
+	t.Run("record with invalid checksum", func(t *testing.T) {
+		dir := setup(t)
+		seq := lastSegment(t, dir)
+		data, err := os.ReadFile(segmentPath(dir, seq))
+		require.NoError(t, err)
+		data[len(data)-1] ^= 0xFF
+		require.NoError(t, os.WriteFile(segmentPath(dir, seq), data, 0600))
+
+		// complete record is not truncated as it might be followed by valid records
+		_, err = Recover(dir)
+		require.ErrorIs(t, err, ErrCorrupted)
+		require.ErrorContains(t, err, "checksum mismatch of the record")
+		data2, err := os.ReadFile(segmentPath(dir, seq))
+		require.NoError(t, err)
+		require.Equal(t, data, data2)
+	})
+
+	t.Run("torn segment header", func(t *testing.T) {
+		dir := setup(t)
+		seq := lastSegment(t, dir) + 1
+		require.NoError(t, os.WriteFile(segmentPath(dir, seq), []byte(segmentMagic), 0600))
+
+		n, err := Recover(dir)
+		require.NoError(t, err)
+		require.EqualValues(t, len(segmentMagic), n)
+		require.NoFileExists(t, segmentPath(dir, seq))
+		require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, rounds(t, dir))
+	})
+
+	t.Run("missing index is rebuilt", func(t *testing.T) {
+		dir := setup(t)
+		require.NoError(t, os.Remove(indexPath(dir, 0)))
+		_, err := Recover(dir)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, rounds(t, dir))
+	})
+
+	t.Run("damaged sealed segment", func(t *testing.T) {
+		dir := setup(t)
+		data, err := os.ReadFile(segmentPath(dir, 0))
+		require.NoError(t, err)
+		data[len(data)-recordHeaderSize-1] ^= 0xFF
+		require.NoError(t, os.WriteFile(segmentPath(dir, 0), data, 0600))
+		require.NoError(t, os.Remove(indexPath(dir, 0)))
+
+		_, err = Recover(dir)
+		require.ErrorIs(t, err, ErrCorrupted)
+	})
+
+	t.Run("missing segment", func(t *testing.T) {
+		dir := setup(t)
+		require.NoError(t, os.Remove(segmentPath(dir, 0)))
+		_, err := Recover(dir)
+		require.EqualError(t, err, "listing segments: archive is corrupted: segment 0 is missing")
+	})
+}
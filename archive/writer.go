@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+type (
+	/*
+		Writer appends blocks of a shard to the archive. Writer is not safe for
+		concurrent use and there must be only one writer per archive.
+	*/
+	Writer struct {
+		dir            string
+		header         Header
+		maxSegmentSize int64
+
+		seq       uint32      // sequence number of the current segment
+		seg       segmentFile // current segment
+		idx       segmentFile // index of the current segment
+		size      int64       // size of the current segment
+		checksum  uint32      // checksum of the current segment
+		count     uint64      // number of records in the current segment
+		lastRound uint64      // round of the last block in the archive
+		hasBlocks bool
+	}
+
+	// segmentFile is the subset of *os.File methods the writer uses.
+	segmentFile interface {
+		io.WriteCloser
+		Sync() error
+		Truncate(size int64) error
+	}
+
+	WriterOption func(w *Writer)
+)
+
+/*
+WithMaxSegmentSize sets the size after which the writer seals the current
+segment and starts a new one, default is DefaultMaxSegmentSize. Segment always
+contains at least one block so it might grow bigger than the limit.
+*/
+func WithMaxSegmentSize(size int64) WriterOption {
+	return func(w *Writer) {
+		w.maxSegmentSize = size
+	}
+}
+
+/*
+OpenWriter opens the archive of the shard in the "dir" for appending. When the
+directory doesn't contain archive new archive is created, otherwise existing
+archive is recovered (see Recover) and it's header must match the shard.
+*/
+func OpenWriter(dir string, partitionID types.PartitionID, shardID types.ShardID, opts ...WriterOption) (*Writer, error) {
+	w := &Writer{
+		dir:            dir,
+		header:         Header{PartitionID: partitionID, ShardID: shardID},
+		maxSegmentSize: DefaultMaxSegmentSize,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating archive directory: %w", err)
+	}
+	if _, err := Recover(dir); err != nil {
+		return nil, fmt.Errorf("recovering archive: %w", err)
+	}
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing segments: %w", err)
+	}
+	if len(seqs) == 0 {
+		if err := w.createSegment(0); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	h, err := headerOf(segmentPath(dir, 0))
+	if err != nil {
+		return nil, fmt.Errorf("reading archive header: %w", err)
+	}
+	if !h.equal(&w.header) {
+		return nil, fmt.Errorf("archive is of partition %s shard %q, not partition %s shard %q", h.PartitionID, h.ShardID, partitionID, shardID)
+	}
+	if w.lastRound, w.hasBlocks, err = lastArchivedRound(dir, seqs); err != nil {
+		return nil, err
+	}
+	if err := w.openSegment(seqs[len(seqs)-1]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+/*
+Append adds the block to the end of the archive. The block must be of the shard
+of the archive, it must have UC and it's round must be greater than the round
+of the last block in the archive.
+*/
+func (w *Writer) Append(block *types.Block) error {
+	if w.seg == nil {
+		return ErrClosed
+	}
+	if block == nil || block.Header == nil {
+		return errors.New("block or block header is nil")
+	}
+	if block.Header.PartitionID != w.header.PartitionID || !block.Header.ShardID.Equal(w.header.ShardID) {
+		return fmt.Errorf("block is of partition %s shard %q, archive is of partition %s shard %q",
+			block.Header.PartitionID, block.Header.ShardID, w.header.PartitionID, w.header.ShardID)
+	}
+	round, err := block.GetRoundNumber()
+	if err != nil {
+		return fmt.Errorf("reading block round: %w", err)
+	}
+	if w.hasBlocks && round <= w.lastRound {
+		return fmt.Errorf("block of round %d can't follow the block of round %d", round, w.lastRound)
+	}
+	data, err := types.Cbor.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("encoding block: %w", err)
+	}
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("block is too big: %d bytes", len(data))
+	}
+
+	rec := encodeRecord(round, data)
+	if w.count > 0 && w.size+int64(len(rec)) > w.maxSegmentSize {
+		if err := w.rotate(); err != nil {
+			return fmt.Errorf("starting new segment: %w", err)
+		}
+	}
+	if _, err := w.seg.Write(rec); err != nil {
+		return w.rollback(fmt.Errorf("writing block: %w", err))
+	}
+	entry := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, round), uint64(w.size))
+	if _, err := w.idx.Write(entry); err != nil {
+		return w.rollback(fmt.Errorf("writing index: %w", err))
+	}
+	w.size += int64(len(rec))
+	w.checksum = crc32.Update(w.checksum, crcTable, rec)
+	w.count++
+	w.lastRound, w.hasBlocks = round, true
+	return nil
+}
+
+// LastRound returns the round of the last block in the archive, zero when the archive is empty.
+func (w *Writer) LastRound() uint64 { return w.lastRound }
+
+// Sync commits the current segment and it's index to the stable storage.
+func (w *Writer) Sync() error {
+	if w.seg == nil {
+		return ErrClosed
+	}
+	return errors.Join(w.seg.Sync(), w.idx.Sync())
+}
+
+/*
+Close syncs and closes the current segment. The segment is not sealed, ie
+the archive can be opened for appending again.
+*/
+func (w *Writer) Close() error {
+	if w.seg == nil {
+		return ErrClosed
+	}
+	err := errors.Join(w.Sync(), w.seg.Close(), w.idx.Close())
+	w.seg, w.idx = nil, nil
+	return err
+}
+
+/*
+rollback removes the partially written data of the failed write "err" from the
+current segment and it's index (the files are opened in append mode so the next
+write follows the truncated end). When that fails too the writer is closed as
+it's state doesn't match the files anymore.
+*/
+func (w *Writer) rollback(err error) error {
+	terr := errors.Join(w.seg.Truncate(w.size), w.idx.Truncate(int64(w.count)*indexEntrySize))
+	if terr == nil {
+		return err
+	}
+	err = errors.Join(err, fmt.Errorf("rolling back failed write: %w", terr), w.seg.Close(), w.idx.Close())
+	w.seg, w.idx = nil, nil
+	return err
+}
+
+// rotate seals the current segment and starts the next one.
+func (w *Writer) rotate() error {
+	if _, err := w.seg.Write(encodeTrailer(w.checksum, w.count)); err != nil {
+		return w.rollback(fmt.Errorf("writing segment trailer: %w", err))
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("closing segment: %w", err)
+	}
+	return w.createSegment(w.seq + 1)
+}
+
+func (w *Writer) createSegment(seq uint32) error {
+	hdr, err := encodeHeader(&w.header)
+	if err != nil {
+		return err
+	}
+	// #nosec G304
+	seg, err := os.OpenFile(segmentPath(w.dir, seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("creating segment: %w", err)
+	}
+	if _, err := seg.Write(hdr); err != nil {
+		return errors.Join(fmt.Errorf("writing segment header: %w", err), seg.Close())
+	}
+	// #nosec G304
+	idx, err := os.OpenFile(indexPath(w.dir, seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Join(fmt.Errorf("creating index: %w", err), seg.Close())
+	}
+	w.seq, w.seg, w.idx = seq, seg, idx
+	w.size, w.checksum, w.count = int64(len(hdr)), crc32.Checksum(hdr, crcTable), 0
+	return nil
+}
+
+/*
+openSegment opens existing (recovered) segment for appending, when the segment
+is sealed new segment is created.
+*/
+func (w *Writer) openSegment(seq uint32) error {
+	// #nosec G304
+	seg, err := os.OpenFile(segmentPath(w.dir, seq), os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("opening segment: %w", err)
+	}
+	scan, err := scanSegment(seg)
+	if err == nil && scan.err != nil {
+		err = scan.err
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("reading segment %d: %w", seq, err), seg.Close())
+	}
+	if scan.sealed {
+		if err := seg.Close(); err != nil {
+			return err
+		}
+		return w.createSegment(seq + 1)
+	}
+	// #nosec G304
+	idx, err := os.OpenFile(indexPath(w.dir, seq), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Join(fmt.Errorf("opening index: %w", err), seg.Close())
+	}
+	w.seq, w.seg, w.idx = seq, seg, idx
+	w.size, w.checksum, w.count = scan.end, scan.checksum, uint64(len(scan.entries))
+	return nil
+}
+
+// lastArchivedRound returns the round of the last block in the archive, false when the archive is empty.
+func lastArchivedRound(dir string, seqs []uint32) (uint64, bool, error) {
+	for i := len(seqs) - 1; i >= 0; i-- {
+		entries, err := readIndex(dir, seqs[i])
+		if err != nil {
+			return 0, false, fmt.Errorf("segment %d: %w", seqs[i], err)
+		}
+		if len(entries) > 0 {
+			return entries[len(entries)-1].round, true, nil
+		}
+	}
+	return 0, false, nil
+}
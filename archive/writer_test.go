@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+const testPartitionID types.PartitionID = 7
+
+// testBlock returns block of the "round" with "txCount" transactions.
+func testBlock(t *testing.T, round uint64, txCount int) *types.Block {
+	t.Helper()
+	uc, err := (&types.UnicityCertificate{
+		Version:     1,
+		InputRecord: &types.InputRecord{Version: 1, RoundNumber: round},
+	}).MarshalCBOR()
+	require.NoError(t, err)
+	b := &types.Block{
+		Header: &types.Header{
+			Version:     1,
+			PartitionID: testPartitionID,
+			ProposerID:  "test",
+		},
+		Transactions:       []*types.TransactionRecord{},
+		UnicityCertificate: uc,
+	}
+	for i := range txCount {
+		txo, err := types.Cbor.Marshal([]uint64{uint64(i), round})
+		require.NoError(t, err)
+		b.Transactions = append(b.Transactions, &types.TransactionRecord{
+			Version:          1,
+			TransactionOrder: txo,
+			ServerMetadata:   &types.ServerMetadata{ActualFee: uint64(i)},
+		})
+	}
+	return b
+}
+
+func writeBlocks(t *testing.T, w *Writer, rounds ...uint64) {
+	t.Helper()
+	for _, r := range rounds {
+		require.NoError(t, w.Append(testBlock(t, r, int(r%3))))
+	}
+}
+
+// failingFile simulates failed writes of the segment or index file.
+type failingFile struct {
+	segmentFile
+	failWrite    bool
+	failTruncate bool
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if f.failWrite {
+		// only part of the data gets written
+		n, _ := f.segmentFile.Write(b[:len(b)/2])
+		return n, errors.New("disk full")
+	}
+	return f.segmentFile.Write(b)
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("truncate failed")
+	}
+	return f.segmentFile.Truncate(size)
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("append and reopen", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		require.Zero(t, w.LastRound())
+		writeBlocks(t, w, 1, 2, 4)
+		require.EqualValues(t, 4, w.LastRound())
+		require.NoError(t, w.Close())
+		require.ErrorIs(t, w.Close(), ErrClosed)
+		require.ErrorIs(t, w.Append(testBlock(t, 5, 0)), ErrClosed)
+
+		w, err = OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		require.EqualValues(t, 4, w.LastRound())
+		require.EqualError(t, w.Append(testBlock(t, 4, 0)), "block of round 4 can't follow the block of round 4")
+		writeBlocks(t, w, 5)
+		require.NoError(t, w.Close())
+
+		r, err := OpenReader(dir)
+		require.NoError(t, err)
+		defer r.Close()
+		require.NoError(t, r.Verify())
+		require.EqualValues(t, 1, r.FirstRound())
+		require.EqualValues(t, 5, r.LastRound())
+	})
+
+	t.Run("segment rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+		require.NoError(t, err)
+		writeBlocks(t, w, 1, 2, 3, 4, 5, 6, 7, 8)
+		require.NoError(t, w.Close())
+
+		seqs, err := listSegments(dir)
+		require.NoError(t, err)
+		require.Greater(t, len(seqs), 2)
+		for _, seq := range seqs[:len(seqs)-1] {
+			f, err := os.Open(segmentPath(dir, seq))
+			require.NoError(t, err)
+			scan, err := scanSegment(f)
+			require.NoError(t, err)
+			require.NoError(t, scan.err)
+			require.True(t, scan.sealed)
+			require.NoError(t, f.Close())
+		}
+
+		// reopening continues in the last segment
+		w, err = OpenWriter(dir, testPartitionID, types.ShardID{}, WithMaxSegmentSize(300))
+		require.NoError(t, err)
+		writeBlocks(t, w, 9, 10)
+		require.NoError(t, w.Close())
+
+		r, err := OpenReader(dir)
+		require.NoError(t, err)
+		defer r.Close()
+		require.NoError(t, r.Verify())
+		for round := uint64(1); round <= 10; round++ {
+			b, err := r.Block(round)
+			require.NoError(t, err)
+			require.Equal(t, testBlock(t, round, int(round%3)), b)
+		}
+	})
+
+	t.Run("invalid blocks", func(t *testing.T) {
+		w, err := OpenWriter(t.TempDir(), testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		defer w.Close()
+
+		require.EqualError(t, w.Append(nil), "block or block header is nil")
+		b := testBlock(t, 1, 0)
+		b.Header.PartitionID = 8
+		require.EqualError(t, w.Append(b), `block is of partition 00000008 shard "", archive is of partition 00000007 shard ""`)
+		b = testBlock(t, 1, 0)
+		b.UnicityCertificate = nil
+		require.ErrorContains(t, w.Append(b), "reading block round")
+	})
+
+	t.Run("shard mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		shardID, _ := types.ShardID{}.Split()
+		_, err = OpenWriter(dir, testPartitionID, shardID)
+		require.EqualError(t, err, `archive is of partition 00000007 shard "", not partition 00000007 shard "0"`)
+	})
+}
+
+func TestWriter_FailedWrite(t *testing.T) {
+	t.Run("failed writes are rolled back", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		seg, idx := &failingFile{segmentFile: w.seg}, &failingFile{segmentFile: w.idx}
+		w.seg, w.idx = seg, idx
+		writeBlocks(t, w, 1)
+
+		seg.failWrite = true
+		require.EqualError(t, w.Append(testBlock(t, 2, 1)), "writing block: disk full")
+		seg.failWrite, idx.failWrite = false, true
+		require.EqualError(t, w.Append(testBlock(t, 2, 1)), "writing index: disk full")
+		idx.failWrite = false
+		require.EqualValues(t, 1, w.LastRound())
+
+		// the next block follows the last written block
+		writeBlocks(t, w, 2, 3)
+		require.NoError(t, w.Close())
+
+		n, err := Recover(dir)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		r, err := OpenReader(dir)
+		require.NoError(t, err)
+		defer r.Close()
+		require.NoError(t, r.Verify())
+		for round := uint64(1); round <= 3; round++ {
+			b, err := r.Block(round)
+			require.NoError(t, err)
+			require.Equal(t, testBlock(t, round, int(round%3)), b)
+		}
+	})
+
+	t.Run("failed rollback closes the writer", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		seg := &failingFile{segmentFile: w.seg, failWrite: true, failTruncate: true}
+		w.seg = seg
+
+		require.EqualError(t, w.Append(testBlock(t, 1, 0)), "writing block: disk full\nrolling back failed write: truncate failed")
+		require.ErrorIs(t, w.Append(testBlock(t, 1, 0)), ErrClosed)
+		require.ErrorIs(t, w.Close(), ErrClosed)
+
+		// torn record is removed by the recovery
+		n, err := Recover(dir)
+		require.NoError(t, err)
+		require.Positive(t, n)
+		w, err = OpenWriter(dir, testPartitionID, types.ShardID{})
+		require.NoError(t, err)
+		writeBlocks(t, w, 1)
+		require.NoError(t, w.Close())
+	})
+}
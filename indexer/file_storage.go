@@ -0,0 +1,197 @@
+package indexer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+var _ Storage = (*FileStorage)(nil)
+
+// ErrCorrupted is returned when the storage log contains invalid record.
+var ErrCorrupted = errors.New("storage file is corrupted")
+
+/*
+FileStorage is the file-backed implementation of the Storage. Entries are
+appended to the log file as length-prefixed and checksummed CBOR records (one
+record per block) and are kept in memory for lookups, the log is replayed when
+the storage is opened.
+*/
+type FileStorage struct {
+	mu  sync.Mutex
+	f   storageFile
+	mem *MemoryStorage
+}
+
+// storageFile is the subset of the *os.File methods used by the FileStorage.
+type storageFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+	Stat() (os.FileInfo, error)
+}
+
+const (
+	// maxRecordSize is the sanity limit of the size of the log record.
+	maxRecordSize = 1 << 30
+	// size of the record header: length and CRC32C checksum of the record data
+	recordHeaderSize = 8
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type fileRecord struct {
+	_       struct{} `cbor:",toarray"`
+	Round   uint64
+	Entries []UnitEntry
+}
+
+/*
+NewFileStorage opens (or creates) the storage log file at "path". Record torn
+by interrupted write at the end of the log is discarded, invalid record inside
+the log causes ErrCorrupted error.
+*/
+func NewFileStorage(path string) (*FileStorage, error) {
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening storage file: %w", err)
+	}
+	s := &FileStorage{f: f, mem: NewMemoryStorage()}
+	if err := s.replay(); err != nil {
+		return nil, errors.Join(fmt.Errorf("reading storage file: %w", err), f.Close())
+	}
+	return s, nil
+}
+
+/*
+replay loads the records of the log into memory. Only the last record of the
+log may be torn (the record extends beyond the end of the file or it's data
+doesn't match the checksum), it is the result of the interrupted write and is
+discarded.
+*/
+func (s *FileStorage) replay() error {
+	fi, err := s.f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading file info: %w", err)
+	}
+	fileSize := fi.Size()
+	r := bufio.NewReader(s.f)
+	var offset int64
+	hdr := make([]byte, recordHeaderSize)
+	for offset < fileSize {
+		if fileSize-offset < recordHeaderSize {
+			return s.truncate(offset)
+		}
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return fmt.Errorf("reading record header at offset %d: %w", offset, err)
+		}
+		size := int64(binary.BigEndian.Uint32(hdr))
+		if offset+recordHeaderSize+size > fileSize {
+			return s.truncate(offset)
+		}
+		if size > maxRecordSize {
+			return fmt.Errorf("%w: invalid record length %d at offset %d", ErrCorrupted, size, offset)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("reading record at offset %d: %w", offset, err)
+		}
+		// (zero filled) record written only partially before crash
+		lastRecord := offset+recordHeaderSize+size == fileSize
+		if crc32.Checksum(data, crcTable) != binary.BigEndian.Uint32(hdr[4:]) {
+			if lastRecord {
+				return s.truncate(offset)
+			}
+			return fmt.Errorf("%w: checksum mismatch of the record at offset %d", ErrCorrupted, offset)
+		}
+		rec := &fileRecord{}
+		if err := types.Cbor.Unmarshal(data, rec); err != nil {
+			if lastRecord {
+				return s.truncate(offset)
+			}
+			return fmt.Errorf("%w: decoding record at offset %d: %w", ErrCorrupted, offset, err)
+		}
+		if last, ok := s.mem.lastRound, s.mem.hasRounds; ok && rec.Round <= last {
+			return fmt.Errorf("record of round %d follows the record of round %d", rec.Round, last)
+		}
+		s.mem.add(rec.Round, rec.Entries)
+		offset += recordHeaderSize + size
+	}
+	_, err = s.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+// truncate discards the tail of the log starting from "offset".
+func (s *FileStorage) truncate(offset int64) error {
+	if err := s.f.Truncate(offset); err != nil {
+		return fmt.Errorf("truncating torn record: %w", err)
+	}
+	_, err := s.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+/*
+Store appends the entries of the round to the log. When writing the record
+fails the log is truncated to it's previous size so the partially written
+record doesn't corrupt the log.
+*/
+func (s *FileStorage) Store(round uint64, entries []UnitEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("storage is closed")
+	}
+	if last, ok, _ := s.mem.LastRound(); ok && round <= last {
+		return fmt.Errorf("round %d is already indexed, last indexed round is %d", round, last)
+	}
+	data, err := types.Cbor.Marshal(&fileRecord{Round: round, Entries: entries})
+	if err != nil {
+		return fmt.Errorf("encoding entries: %w", err)
+	}
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("entries of the round %d are too big: %d bytes", round, len(data))
+	}
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(data, crcTable))
+
+	offset, err := s.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("reading storage file offset: %w", err)
+	}
+	if _, err := s.f.Write(append(buf, data...)); err != nil {
+		return errors.Join(fmt.Errorf("writing entries: %w", err), s.truncate(offset))
+	}
+	if err := s.f.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("syncing storage file: %w", err), s.truncate(offset))
+	}
+	return s.mem.Store(round, entries)
+}
+
+func (s *FileStorage) History(unitID types.UnitID) ([]Entry, error) {
+	return s.mem.History(unitID)
+}
+
+func (s *FileStorage) LastRound() (uint64, bool, error) {
+	return s.mem.LastRound()
+}
+
+func (s *FileStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
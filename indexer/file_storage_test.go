@@ -0,0 +1,193 @@
+package indexer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failingFile simulates failed writes of the storage file.
+type failingFile struct {
+	storageFile
+	failWrite bool
+	failSync  bool
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if f.failWrite {
+		// only part of the data gets written
+		n, _ := f.storageFile.Write(b[:len(b)/2])
+		return n, errors.New("disk full")
+	}
+	return f.storageFile.Write(b)
+}
+
+func (f *failingFile) Sync() error {
+	if f.failSync {
+		return errors.New("sync failed")
+	}
+	return f.storageFile.Sync()
+}
+
+func TestFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+
+	e1 := UnitEntry{UnitID: unitID(1), Entry: Entry{Round: 1, TxIndex: 0, TxHash: []byte{1}}}
+	e2 := UnitEntry{UnitID: unitID(1), Entry: Entry{Round: 3, TxIndex: 2, TxHash: []byte{2}}}
+	e3 := UnitEntry{UnitID: unitID(2), Entry: Entry{Round: 3, TxIndex: 2, TxHash: []byte{2}}}
+	require.NoError(t, s.Store(1, []UnitEntry{e1}))
+	require.NoError(t, s.Store(2, nil))
+	require.NoError(t, s.Store(3, []UnitEntry{e2, e3}))
+	require.EqualError(t, s.Store(3, nil), "round 3 is already indexed, last indexed round is 3")
+	require.NoError(t, s.Close())
+	require.EqualError(t, s.Store(4, nil), "storage is closed")
+
+	t.Run("reopen", func(t *testing.T) {
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		defer s.Close()
+		last, ok, err := s.LastRound()
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.EqualValues(t, 3, last)
+		h, err := s.History(unitID(1))
+		require.NoError(t, err)
+		require.Equal(t, []Entry{e1.Entry, e2.Entry}, h)
+		h, err = s.History(unitID(2))
+		require.NoError(t, err)
+		require.Equal(t, []Entry{e3.Entry}, h)
+	})
+
+	t.Run("torn write is discarded", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		size := len(data)
+		require.NoError(t, os.WriteFile(path, append(data, 0, 0, 0, 20, 1, 2), 0600))
+
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		last, _, err := s.LastRound()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, last)
+		// storage is usable after recovery
+		require.NoError(t, s.Store(4, []UnitEntry{{UnitID: unitID(1), Entry: Entry{Round: 4, TxHash: []byte{4}}}}))
+		require.NoError(t, s.Close())
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Greater(t, fi.Size(), int64(size))
+
+		s, err = NewFileStorage(path)
+		require.NoError(t, err)
+		defer s.Close()
+		h, err := s.History(unitID(1))
+		require.NoError(t, err)
+		require.Len(t, h, 3)
+		require.EqualValues(t, 4, h[2].Round)
+	})
+
+	t.Run("incomplete last record is discarded", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		// header of 100 byte record followed by 10 bytes of data
+		require.NoError(t, os.WriteFile(path, append(data, append([]byte{0, 0, 0, 100, 1, 2, 3, 4}, make([]byte, 10)...)...), 0600))
+
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		data2, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, data, data2)
+	})
+
+	t.Run("torn last record is discarded", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		// complete record with zero filled data
+		torn := append(append([]byte{}, data...), 0, 0, 0, 4, 1, 2, 3, 4, 0, 0, 0, 0)
+		require.NoError(t, os.WriteFile(path, torn, 0600))
+		s, err := NewFileStorage(path)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		data2, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, data, data2)
+
+		// zero filled header and data
+		require.NoError(t, os.WriteFile(path, append(append([]byte{}, data...), make([]byte, recordHeaderSize)...), 0600))
+		s, err = NewFileStorage(path)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+		data2, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, data, data2)
+	})
+
+	t.Run("corrupted record", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		corrupted := append([]byte{}, data...)
+		// flip a bit in the data of the first record
+		corrupted[recordHeaderSize+2] ^= 1
+		require.NoError(t, os.WriteFile(path, corrupted, 0600))
+
+		_, err = NewFileStorage(path)
+		require.ErrorIs(t, err, ErrCorrupted)
+		require.ErrorContains(t, err, "checksum mismatch of the record at offset 0")
+		// valid records following the corrupted record are not discarded
+		data2, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, corrupted, data2)
+
+		// invalid length of the record
+		corrupted = append([]byte{}, data...)
+		corrupted[0] = 0x7F
+		require.NoError(t, os.WriteFile(path, corrupted, 0600))
+		// extend the (sparse) file so that the record fits into the file
+		require.NoError(t, os.Truncate(path, int64(len(corrupted))+0x7F<<24))
+		_, err = NewFileStorage(path)
+		require.ErrorIs(t, err, ErrCorrupted)
+		require.ErrorContains(t, err, "invalid record length")
+
+		require.NoError(t, os.WriteFile(path, data, 0600))
+	})
+}
+
+func TestFileStorage_FailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	f := &failingFile{storageFile: s.f}
+	s.f = f
+
+	e1 := UnitEntry{UnitID: unitID(1), Entry: Entry{Round: 1, TxHash: []byte{1}}}
+	e2 := UnitEntry{UnitID: unitID(1), Entry: Entry{Round: 2, TxHash: []byte{2}}}
+	require.NoError(t, s.Store(1, []UnitEntry{e1}))
+
+	f.failWrite = true
+	require.EqualError(t, s.Store(2, []UnitEntry{e2}), "writing entries: disk full")
+	f.failWrite = false
+	f.failSync = true
+	require.EqualError(t, s.Store(2, []UnitEntry{e2}), "syncing storage file: sync failed")
+	f.failSync = false
+	// the failed rounds were not stored
+	last, _, err := s.LastRound()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, last)
+
+	// partially written records were removed, the next record follows the last committed one
+	require.NoError(t, s.Store(2, []UnitEntry{e2}))
+	require.NoError(t, s.Close())
+
+	s, err = NewFileStorage(path)
+	require.NoError(t, err)
+	defer s.Close()
+	h, err := s.History(unitID(1))
+	require.NoError(t, err)
+	require.Equal(t, []Entry{e1.Entry, e2.Entry}, h)
+}
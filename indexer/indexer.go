@@ -0,0 +1,163 @@
+/*
+Package indexer builds unit histories from blocks, ie for each unit the list
+of transactions which targeted the unit (according to the
+ServerMetadata.TargetUnits of the transaction record).
+*/
+package indexer
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+var ErrBlockStoreNotSet = errors.New("block store is not set")
+
+type (
+	// BlockStore provides access to the indexed blocks, ie archive.Reader.
+	BlockStore interface {
+		Block(round uint64) (*types.Block, error)
+	}
+
+	/*
+		Indexer consumes blocks of a shard in the order of rounds and stores the
+		unit histories into the Storage.
+	*/
+	Indexer struct {
+		storage       Storage
+		blocks        BlockStore
+		hashAlgorithm crypto.Hash
+	}
+
+	Option func(ix *Indexer)
+)
+
+/*
+New returns indexer which stores the unit histories into "storage". Use
+WithBlockStore to enable generating transaction proofs.
+*/
+func New(storage Storage, algorithm crypto.Hash, opts ...Option) (*Indexer, error) {
+	if storage == nil {
+		return nil, errors.New("storage is nil")
+	}
+	ix := &Indexer{
+		storage:       storage,
+		hashAlgorithm: algorithm,
+	}
+	for _, opt := range opts {
+		opt(ix)
+	}
+	return ix, nil
+}
+
+/*
+WithBlockStore sets the source of blocks used to generate transaction proofs.
+*/
+func WithBlockStore(blocks BlockStore) Option {
+	return func(ix *Indexer) {
+		ix.blocks = blocks
+	}
+}
+
+/*
+IndexBlock adds the transactions of the block to the histories of the units
+they target. Blocks must be indexed in the order of rounds, rounds may be
+skipped (ie empty blocks are not required).
+*/
+func (ix *Indexer) IndexBlock(block *types.Block) error {
+	if block == nil {
+		return errors.New("block is nil")
+	}
+	round, err := block.GetRoundNumber()
+	if err != nil {
+		return fmt.Errorf("reading block round: %w", err)
+	}
+	if len(block.Transactions) > math.MaxUint32 {
+		return fmt.Errorf("block has too many transactions: %d", len(block.Transactions))
+	}
+
+	var entries []UnitEntry
+	for i, txr := range block.Transactions {
+		txo, err := txr.GetTransactionOrderV1()
+		if err != nil {
+			return fmt.Errorf("reading transaction %d of the block: %w", i, err)
+		}
+		txHash, err := txo.Hash(ix.hashAlgorithm)
+		if err != nil {
+			return fmt.Errorf("hashing transaction %d of the block: %w", i, err)
+		}
+		var seen []types.UnitID
+		for _, unitID := range txr.TargetUnits() {
+			if containsUnit(seen, unitID) {
+				continue
+			}
+			seen = append(seen, unitID)
+			entries = append(entries, UnitEntry{
+				UnitID: unitID,
+				Entry:  Entry{Round: round, TxIndex: uint32(i), TxHash: txHash},
+			})
+		}
+	}
+	if err := ix.storage.Store(round, entries); err != nil {
+		return fmt.Errorf("storing entries of round %d: %w", round, err)
+	}
+	return nil
+}
+
+/*
+History returns the transactions which targeted the unit, in the order they
+were executed.
+*/
+func (ix *Indexer) History(unitID types.UnitID) ([]Entry, error) {
+	return ix.storage.History(unitID)
+}
+
+/*
+LastRound returns the round of the last indexed block, false when no block has
+been indexed.
+*/
+func (ix *Indexer) LastRound() (uint64, bool, error) {
+	return ix.storage.LastRound()
+}
+
+/*
+Proof returns the proof of the transaction described by the history entry.
+Requires the block store to be set (see WithBlockStore).
+*/
+func (ix *Indexer) Proof(e Entry) (*types.TxRecordProof, error) {
+	if ix.blocks == nil {
+		return nil, ErrBlockStoreNotSet
+	}
+	block, err := ix.blocks.Block(e.Round)
+	if err != nil {
+		return nil, fmt.Errorf("loading block of round %d: %w", e.Round, err)
+	}
+	if int(e.TxIndex) >= len(block.Transactions) {
+		return nil, fmt.Errorf("block of round %d has %d transactions, entry refers to transaction %d", e.Round, len(block.Transactions), e.TxIndex)
+	}
+	txo, err := block.Transactions[e.TxIndex].GetTransactionOrderV1()
+	if err != nil {
+		return nil, fmt.Errorf("reading transaction: %w", err)
+	}
+	txHash, err := txo.Hash(ix.hashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("hashing transaction: %w", err)
+	}
+	if !bytes.Equal(txHash, e.TxHash) {
+		return nil, fmt.Errorf("transaction %d of the block of round %d has hash %X, expected %X", e.TxIndex, e.Round, txHash, e.TxHash)
+	}
+	return types.NewTxRecordProof(block, int(e.TxIndex), ix.hashAlgorithm)
+}
+
+func containsUnit(ids []types.UnitID, id types.UnitID) bool {
+	for _, v := range ids {
+		if v.Eq(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package indexer
+
+import (
+	"crypto"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+	testuc "github.com/alphabill-org/alphabill-go-base/testutils/uc"
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+type blockMap map[uint64]*types.Block
+
+func (m blockMap) Block(round uint64) (*types.Block, error) {
+	if b, ok := m[round]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("block of round %d not found", round)
+}
+
+var testPDR = &types.PartitionDescriptionRecord{
+	Version:         1,
+	NetworkID:       types.NetworkLocal,
+	PartitionID:     7,
+	PartitionTypeID: 1,
+	TypeIDLen:       8,
+	UnitIDLen:       256,
+	T2Timeout:       2500 * time.Millisecond,
+}
+
+func unitID(b byte) types.UnitID {
+	id := make(types.UnitID, 33)
+	id[31] = b
+	return id
+}
+
+/*
+newBlock returns certified block of the "round", each element of "targets" is
+a transaction and lists the units targeted by the transaction.
+*/
+func newBlock(t *testing.T, signer abcrypto.Signer, round uint64, targets ...[]types.UnitID) *types.Block {
+	t.Helper()
+	block := &types.Block{
+		Header: &types.Header{
+			Version:     1,
+			PartitionID: testPDR.PartitionID,
+			ProposerID:  "proposer",
+		},
+		Transactions: []*types.TransactionRecord{},
+	}
+	for i, units := range targets {
+		attr, err := types.Cbor.Marshal([]uint64{round, uint64(i)})
+		require.NoError(t, err)
+		txo := &types.TransactionOrder{
+			Version: 1,
+			Payload: types.Payload{
+				NetworkID:   testPDR.NetworkID,
+				PartitionID: testPDR.PartitionID,
+				UnitID:      units[0],
+				Type:        1,
+				Attributes:  attr,
+			},
+		}
+		txoBytes, err := txo.MarshalCBOR()
+		require.NoError(t, err)
+		block.Transactions = append(block.Transactions, &types.TransactionRecord{
+			Version:          1,
+			TransactionOrder: txoBytes,
+			ServerMetadata:   &types.ServerMetadata{ActualFee: 1, SuccessIndicator: types.TxStatusSuccessful, TargetUnits: units},
+		})
+	}
+	prevHash, hash := []byte{byte(round - 1)}, []byte{byte(round)}
+	blockHash, err := types.BlockHash(crypto.SHA256, block.Header, block.Transactions, hash, prevHash)
+	require.NoError(t, err)
+	ir := &types.InputRecord{
+		Version:      1,
+		RoundNumber:  round,
+		PreviousHash: prevHash,
+		Hash:         hash,
+		BlockHash:    blockHash,
+		SummaryValue: []byte{0},
+		Timestamp:    types.NewTimestamp(),
+	}
+	uc := testuc.CreateUnicityCertificate(t, "test", signer, ir, testPDR,
+		&types.UnicitySeal{NetworkID: testPDR.NetworkID, RootChainRoundNumber: round + 100, Timestamp: types.NewTimestamp()})
+	block.UnicityCertificate, err = uc.MarshalCBOR()
+	require.NoError(t, err)
+	return block
+}
+
+func TestIndexer(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	pubKey, err := verifier.MarshalPublicKey()
+	require.NoError(t, err)
+	tb, err := types.NewTrustBaseGenesis(types.NetworkLocal, []*types.NodeInfo{{NodeID: "test", SigKey: pubKey, Stake: 1}})
+	require.NoError(t, err)
+
+	u1, u2, u3 := unitID(1), unitID(2), unitID(3)
+	blocks := blockMap{
+		1: newBlock(t, signer, 1, []types.UnitID{u1}, []types.UnitID{u2, u1}),
+		2: newBlock(t, signer, 2),
+		4: newBlock(t, signer, 4, []types.UnitID{u3}, []types.UnitID{u1, u1}),
+	}
+
+	storages := map[string]func(t *testing.T) Storage{
+		"memory": func(t *testing.T) Storage { return NewMemoryStorage() },
+		"file": func(t *testing.T) Storage {
+			s, err := NewFileStorage(filepath.Join(t.TempDir(), "index.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, s.Close()) })
+			return s
+		},
+	}
+	for name, newStorage := range storages {
+		t.Run(name, func(t *testing.T) {
+			ix, err := New(newStorage(t), crypto.SHA256, WithBlockStore(blocks))
+			require.NoError(t, err)
+			_, ok, err := ix.LastRound()
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			for _, round := range []uint64{1, 2, 4} {
+				require.NoError(t, ix.IndexBlock(blocks[round]))
+			}
+			last, ok, err := ix.LastRound()
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.EqualValues(t, 4, last)
+			require.ErrorContains(t, ix.IndexBlock(blocks[2]), "round 2 is already indexed, last indexed round is 4")
+
+			h1, err := ix.History(u1)
+			require.NoError(t, err)
+			require.Len(t, h1, 3)
+			require.Equal(t, []uint64{1, 1, 4}, []uint64{h1[0].Round, h1[1].Round, h1[2].Round})
+			require.Equal(t, []uint32{0, 1, 1}, []uint32{h1[0].TxIndex, h1[1].TxIndex, h1[2].TxIndex})
+
+			h2, err := ix.History(u2)
+			require.NoError(t, err)
+			require.Len(t, h2, 1)
+			require.Equal(t, h1[1], h2[0])
+
+			h, err := ix.History(unitID(9))
+			require.NoError(t, err)
+			require.Empty(t, h)
+
+			for _, e := range h1 {
+				proof, err := ix.Proof(e)
+				require.NoError(t, err)
+				require.NoError(t, types.VerifyTxInclusion(proof, tb, crypto.SHA256))
+				txo, err := proof.GetTransactionOrderV1()
+				require.NoError(t, err)
+				txHash, err := txo.Hash(crypto.SHA256)
+				require.NoError(t, err)
+				require.EqualValues(t, e.TxHash, txHash)
+			}
+		})
+	}
+
+	t.Run("proof errors", func(t *testing.T) {
+		ix, err := New(NewMemoryStorage(), crypto.SHA256)
+		require.NoError(t, err)
+		require.NoError(t, ix.IndexBlock(blocks[1]))
+		h, err := ix.History(u1)
+		require.NoError(t, err)
+		_, err = ix.Proof(h[0])
+		require.ErrorIs(t, err, ErrBlockStoreNotSet)
+
+		ix.blocks = blocks
+		e := h[0]
+		e.TxIndex = 5
+		_, err = ix.Proof(e)
+		require.EqualError(t, err, "block of round 1 has 2 transactions, entry refers to transaction 5")
+
+		e = h[0]
+		e.TxHash = []byte{1, 2, 3}
+		_, err = ix.Proof(e)
+		require.ErrorContains(t, err, "transaction 0 of the block of round 1 has hash")
+
+		e.Round = 3
+		_, err = ix.Proof(e)
+		require.EqualError(t, err, "loading block of round 3: block of round 3 not found")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := New(nil, crypto.SHA256)
+		require.EqualError(t, err, "storage is nil")
+
+		ix, err := New(NewMemoryStorage(), crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, ix.IndexBlock(nil), "block is nil")
+		require.ErrorContains(t, ix.IndexBlock(&types.Block{}), "reading block round")
+	})
+}
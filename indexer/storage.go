@@ -0,0 +1,92 @@
+package indexer
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var _ Storage = (*MemoryStorage)(nil)
+
+type (
+	/*
+		Entry describes transaction which targeted the unit.
+	*/
+	Entry struct {
+		_       struct{}  `cbor:",toarray"`
+		Round   uint64    `json:"round"`   // round of the block containing the transaction
+		TxIndex uint32    `json:"txIndex"` // index of the transaction in the block
+		TxHash  hex.Bytes `json:"txHash"`  // hash of the transaction order
+	}
+
+	/*
+		UnitEntry pairs the history entry with the unit it belongs to.
+	*/
+	UnitEntry struct {
+		_      struct{} `cbor:",toarray"`
+		UnitID types.UnitID
+		Entry  Entry
+	}
+
+	/*
+		Storage persists the unit histories built by the Indexer.
+	*/
+	Storage interface {
+		// Store adds the entries of the block of the given round, the round must be
+		// greater than the last stored round. Entries of the block must be stored
+		// atomically, ie either all or none of them.
+		Store(round uint64, entries []UnitEntry) error
+		// History returns the entries of the unit in the order of rounds and
+		// transaction indexes, nil when the unit has no history.
+		History(unitID types.UnitID) ([]Entry, error)
+		// LastRound returns the last stored round, false when nothing has been stored.
+		LastRound() (uint64, bool, error)
+	}
+)
+
+/*
+MemoryStorage is the in-memory implementation of the Storage.
+*/
+type MemoryStorage struct {
+	mu        sync.RWMutex
+	units     map[string][]Entry
+	lastRound uint64
+	hasRounds bool
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{units: make(map[string][]Entry)}
+}
+
+func (s *MemoryStorage) Store(round uint64, entries []UnitEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hasRounds && round <= s.lastRound {
+		return fmt.Errorf("round %d is already indexed, last indexed round is %d", round, s.lastRound)
+	}
+	s.add(round, entries)
+	return nil
+}
+
+func (s *MemoryStorage) add(round uint64, entries []UnitEntry) {
+	for _, e := range entries {
+		key := string(e.UnitID)
+		s.units[key] = append(s.units[key], e.Entry)
+	}
+	s.lastRound, s.hasRounds = round, true
+}
+
+func (s *MemoryStorage) History(unitID types.UnitID) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.units[string(unitID)]), nil
+}
+
+func (s *MemoryStorage) LastRound() (uint64, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastRound, s.hasRounds, nil
+}
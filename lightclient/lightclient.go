@@ -0,0 +1,244 @@
+/*
+Package lightclient implements a client which follows the certified state of
+partition shards without running a node.
+
+The client ingests unicity certificates from an untrusted source, verifies them
+against the root trust base of the certificate's epoch and keeps track of the
+certified head of each shard. Proofs (TxRecordProof, UnitStateProof) can then be
+verified against the client's certified view.
+*/
+package lightclient
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+var (
+	ErrUnknownEpoch = errors.New("unknown epoch")
+	ErrUnknownShard = errors.New("no certified state for the shard")
+	ErrRoundNotSeen = errors.New("round has not been certified by the client")
+	ErrUCMismatch   = errors.New("unicity certificate does not match certified state")
+)
+
+type (
+	Client struct {
+		trustBases *TrustBaseStore
+		validator  *types.UCValidator
+		tracker    *types.UCTracker
+		algorithm  crypto.Hash
+		maxHistory int
+
+		// certified input records of the shards by round number
+		certified map[types.PartitionShardID]*shardHistory
+		mu        sync.RWMutex
+	}
+
+	// Head is the latest certified state of a shard.
+	Head struct {
+		PartitionID     types.PartitionID
+		ShardID         types.ShardID
+		RoundNumber     uint64
+		RootRoundNumber uint64
+		StateHash       []byte
+		SummaryValue    []byte
+		UC              *types.UnicityCertificate
+	}
+
+	shardHistory struct {
+		rounds []uint64 // round numbers in ascending order
+		irs    map[uint64]*types.InputRecord
+	}
+
+	Option func(c *Client)
+)
+
+/*
+New returns light client which verifies UCs against the trust bases in the
+"trustBases" store and against the shard configurations returned by "shardConf".
+*/
+func New(trustBases *TrustBaseStore, shardConf types.ShardConfLookup, opts ...Option) (*Client, error) {
+	if trustBases == nil {
+		return nil, errors.New("trust base store is nil")
+	}
+	c := &Client{
+		trustBases: trustBases,
+		algorithm:  crypto.SHA256,
+		certified:  make(map[types.PartitionShardID]*shardHistory),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracker == nil {
+		c.tracker = types.NewUCTracker(nil)
+	}
+	var err error
+	if c.validator, err = types.NewUCValidator(trustBases.GetTrustBase, shardConf, c.algorithm); err != nil {
+		return nil, fmt.Errorf("creating UC validator: %w", err)
+	}
+	return c, nil
+}
+
+// WithHashAlgorithm sets the hash algorithm used by the partitions, default is SHA256.
+func WithHashAlgorithm(algorithm crypto.Hash) Option {
+	return func(c *Client) {
+		c.algorithm = algorithm
+	}
+}
+
+// WithUCStore sets the store used to persist the latest UCs of the shards.
+func WithUCStore(store types.UCStore) Option {
+	return func(c *Client) {
+		c.tracker = types.NewUCTracker(store)
+	}
+}
+
+/*
+WithMaxHistory limits the number of certified rounds remembered per shard,
+proofs referring to older rounds can't be verified. Zero means no limit.
+*/
+func WithMaxHistory(rounds int) Option {
+	return func(c *Client) {
+		c.maxHistory = rounds
+	}
+}
+
+/*
+Ingest verifies the UC and, if it's valid and doesn't equivocate with the
+latest UC of the shard, advances the certified head of the shard.
+*/
+func (c *Client) Ingest(uc *types.UnicityCertificate) (types.UCClass, error) {
+	if uc == nil {
+		return 0, types.ErrUnicityCertificateIsNil
+	}
+	if err := c.validator.Validate(uc, nil); err != nil {
+		return 0, fmt.Errorf("invalid unicity certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	class, err := c.tracker.Update(uc)
+	if err != nil {
+		return 0, err
+	}
+	if class == types.UCFirstSeen || class == types.UCNewRound {
+		c.addRound(uc)
+	}
+	return class, nil
+}
+
+func (c *Client) addRound(uc *types.UnicityCertificate) {
+	id := types.PartitionShardID{PartitionID: uc.GetPartitionID(), ShardID: uc.GetShardID().Key()}
+	sh, ok := c.certified[id]
+	if !ok {
+		sh = &shardHistory{irs: make(map[uint64]*types.InputRecord)}
+		c.certified[id] = sh
+	}
+	round := uc.GetRoundNumber()
+	sh.rounds = append(sh.rounds, round)
+	sh.irs[round] = uc.InputRecord
+	if c.maxHistory > 0 && len(sh.rounds) > c.maxHistory {
+		delete(sh.irs, sh.rounds[0])
+		sh.rounds = sh.rounds[1:]
+	}
+}
+
+/*
+Head returns the latest certified state of the shard.
+*/
+func (c *Client) Head(partition types.PartitionID, shard types.ShardID) (*Head, error) {
+	uc, err := c.tracker.Latest(partition, shard)
+	if err != nil {
+		return nil, fmt.Errorf("reading the latest UC: %w", err)
+	}
+	if uc == nil {
+		return nil, fmt.Errorf("%w: partition %s shard %q", ErrUnknownShard, partition, shard)
+	}
+	return &Head{
+		PartitionID:     partition,
+		ShardID:         shard,
+		RoundNumber:     uc.GetRoundNumber(),
+		RootRoundNumber: uc.GetRootRoundNumber(),
+		StateHash:       uc.GetStateHash(),
+		SummaryValue:    uc.GetSummaryValue(),
+		UC:              uc,
+	}, nil
+}
+
+/*
+VerifyTxRecordProof verifies that the transaction is included in a block
+certified by the UC the client has seen.
+*/
+func (c *Client) VerifyTxRecordProof(proof *types.TxRecordProof) error {
+	if proof == nil {
+		return types.ErrTxRecordProofIsNil
+	}
+	uc, err := proof.TxProof.GetUC()
+	if err != nil {
+		return fmt.Errorf("reading UC of the proof: %w", err)
+	}
+	if err := c.checkCertified(uc); err != nil {
+		return err
+	}
+	tb, err := c.trustBases.GetTrustBase(uc.UnicitySeal.Epoch)
+	if err != nil {
+		return fmt.Errorf("acquiring trust base: %w", err)
+	}
+	return types.VerifyTxInclusion(proof, tb, c.algorithm)
+}
+
+/*
+VerifyUnitStateProof verifies that the "state" is the state of the unit in
+the round certified by the UC the client has seen.
+*/
+func (c *Client) VerifyUnitStateProof(proof *types.UnitStateProof, state *types.UnitState) error {
+	if err := proof.IsValid(); err != nil {
+		return fmt.Errorf("invalid unit state proof: %w", err)
+	}
+	uc, err := proof.GetUC()
+	if err != nil {
+		return fmt.Errorf("reading UC of the proof: %w", err)
+	}
+	if err := c.checkCertified(uc); err != nil {
+		return err
+	}
+	return proof.Verify(c.algorithm, state, c.validator, nil)
+}
+
+/*
+checkCertified checks that the UC certifies the same input record as the UC
+the client has accepted for the round.
+*/
+func (c *Client) checkCertified(uc *types.UnicityCertificate) error {
+	if uc.InputRecord == nil {
+		return types.ErrInputRecordIsNil
+	}
+	if uc.UnicityTreeCertificate == nil {
+		return types.ErrUnicityTreeCertificateIsNil
+	}
+	if uc.UnicitySeal == nil {
+		return types.ErrUnicitySealIsNil
+	}
+	partition, shard, round := uc.GetPartitionID(), uc.GetShardID(), uc.GetRoundNumber()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sh, ok := c.certified[types.PartitionShardID{PartitionID: partition, ShardID: shard.Key()}]
+	if !ok {
+		return fmt.Errorf("%w: partition %s shard %q", ErrUnknownShard, partition, shard)
+	}
+	ir, ok := sh.irs[round]
+	if !ok {
+		return fmt.Errorf("%w: proof is for round %d, certified rounds are %d..%d", ErrRoundNotSeen, round, sh.rounds[0], sh.rounds[len(sh.rounds)-1])
+	}
+	if err := types.AssertEqualIR(ir, uc.InputRecord); err != nil {
+		return fmt.Errorf("%w: %w", ErrUCMismatch, err)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package lightclient
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+	testuc "github.com/alphabill-org/alphabill-go-base/testutils/uc"
+	"github.com/alphabill-org/alphabill-go-base/types"
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+func TestClient(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("invalid constructor arguments", func(t *testing.T) {
+		c, err := New(nil, env.pdrLookup)
+		require.EqualError(t, err, "trust base store is nil")
+		require.Nil(t, c)
+
+		c, err = New(env.trustBases, nil)
+		require.EqualError(t, err, "creating UC validator: shard configuration lookup is nil")
+		require.Nil(t, c)
+	})
+
+	t.Run("follow shard head", func(t *testing.T) {
+		c, err := New(env.trustBases, env.pdrLookup)
+		require.NoError(t, err)
+
+		head, err := c.Head(env.pdr.PartitionID, types.ShardID{})
+		require.ErrorIs(t, err, ErrUnknownShard)
+		require.Nil(t, head)
+
+		r1 := env.newRound(t, 1, 10, []byte{1}, []byte{2})
+		class, err := c.Ingest(r1.uc)
+		require.NoError(t, err)
+		require.Equal(t, types.UCFirstSeen, class)
+
+		r2 := env.newRound(t, 2, 11, []byte{2}, []byte{3})
+		class, err = c.Ingest(r2.uc)
+		require.NoError(t, err)
+		require.Equal(t, types.UCNewRound, class)
+
+		head, err = c.Head(env.pdr.PartitionID, types.ShardID{})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, head.RoundNumber)
+		require.EqualValues(t, 11, head.RootRoundNumber)
+		require.EqualValues(t, []byte{3}, head.StateHash)
+		require.EqualValues(t, r2.uc.InputRecord.SummaryValue, head.SummaryValue)
+
+		// stale UC is rejected
+		_, err = c.Ingest(r1.uc)
+		require.ErrorIs(t, err, types.ErrStaleUC)
+
+		// equivocating UC is rejected
+		r2b := env.newRound(t, 3, 12, []byte{9}, []byte{4})
+		_, err = c.Ingest(r2b.uc)
+		require.ErrorIs(t, err, types.ErrEquivocatingUC)
+	})
+
+	t.Run("UC not signed by the trust base", func(t *testing.T) {
+		c, err := New(env.trustBases, env.pdrLookup)
+		require.NoError(t, err)
+
+		signer, _ := testsig.CreateSignerAndVerifier(t)
+		ir := env.newIR(1, []byte{1}, []byte{2}, []byte{3})
+		uc := testuc.CreateUnicityCertificate(t, "test", signer, ir, env.pdr, &types.UnicitySeal{NetworkID: env.pdr.NetworkID, RootChainRoundNumber: 10, Epoch: 1, Timestamp: types.NewTimestamp()})
+		_, err = c.Ingest(uc)
+		require.ErrorContains(t, err, "invalid unicity certificate: verifying unicity seal: verifying signatures: quorum not reached")
+
+		// UC from unknown epoch
+		uc = testuc.CreateUnicityCertificate(t, "test", env.signer, ir, env.pdr, &types.UnicitySeal{NetworkID: env.pdr.NetworkID, RootChainRoundNumber: 10, Epoch: 2, Timestamp: types.NewTimestamp()})
+		_, err = c.Ingest(uc)
+		require.ErrorIs(t, err, ErrUnknownEpoch)
+	})
+
+	t.Run("verify tx record proof", func(t *testing.T) {
+		c, err := New(env.trustBases, env.pdrLookup, WithMaxHistory(2))
+		require.NoError(t, err)
+
+		r1 := env.newRound(t, 1, 10, []byte{1}, []byte{2})
+		r2 := env.newRound(t, 2, 11, []byte{2}, []byte{3})
+		r3 := env.newRound(t, 3, 12, []byte{3}, []byte{4})
+		r4 := env.newRound(t, 4, 13, []byte{4}, []byte{5})
+
+		proof, err := types.NewTxRecordProof(r2.block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		require.ErrorIs(t, c.VerifyTxRecordProof(proof), ErrUnknownShard)
+
+		for _, r := range []*testRound{r1, r2, r3} {
+			_, err := c.Ingest(r.uc)
+			require.NoError(t, err)
+		}
+		require.NoError(t, c.VerifyTxRecordProof(proof))
+
+		// proof from the round client hasn't seen yet
+		proof, err = types.NewTxRecordProof(r4.block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		err = c.VerifyTxRecordProof(proof)
+		require.ErrorIs(t, err, ErrRoundNotSeen)
+		require.EqualError(t, err, "round has not been certified by the client: proof is for round 4, certified rounds are 2..3")
+
+		// round dropped from history
+		proof, err = types.NewTxRecordProof(r1.block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		require.ErrorIs(t, c.VerifyTxRecordProof(proof), ErrRoundNotSeen)
+
+		// proof with UC which doesn't match certified state
+		r3b := env.newRound(t, 3, 12, []byte{3}, []byte{7})
+		proof, err = types.NewTxRecordProof(r3b.block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		err = c.VerifyTxRecordProof(proof)
+		require.ErrorIs(t, err, ErrUCMismatch)
+	})
+
+	t.Run("verify unit state proof", func(t *testing.T) {
+		c, err := New(env.trustBases, env.pdrLookup)
+		require.NoError(t, err)
+
+		unitState := &types.UnitState{Data: []byte{0x80}}
+		unitStateHash, err := unitState.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		proof := &types.UnitStateProof{
+			Version:       1,
+			UnitID:        make(types.UnitID, 33),
+			UnitTreeCert:  &types.UnitTreeCert{UnitStateHash: unitStateHash},
+			StateTreeCert: &types.StateTreeCert{},
+		}
+		stateRoot, _, err := proof.CalculateStateTreeOutput(crypto.SHA256)
+		require.NoError(t, err)
+
+		r1 := env.newRound(t, 1, 10, []byte{1}, stateRoot)
+		proof.UnicityCertificate, err = r1.uc.MarshalCBOR()
+		require.NoError(t, err)
+		require.ErrorIs(t, c.VerifyUnitStateProof(proof, unitState), ErrUnknownShard)
+
+		_, err = c.Ingest(r1.uc)
+		require.NoError(t, err)
+		require.NoError(t, c.VerifyUnitStateProof(proof, unitState))
+
+		require.EqualError(t, c.VerifyUnitStateProof(proof, &types.UnitState{}), "unit state hash does not match unit state hash in unit tree cert")
+		require.EqualError(t, c.VerifyUnitStateProof(nil, unitState), "invalid unit state proof: unit state proof is nil")
+	})
+}
+
+func TestTrustBaseStore(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	pubKey, err := verifier.MarshalPublicKey()
+	require.NoError(t, err)
+	genesis, err := types.NewTrustBaseGenesis(types.NetworkLocal, []*types.NodeInfo{{NodeID: "1", SigKey: pubKey, Stake: 1}})
+	require.NoError(t, err)
+
+	store, err := NewTrustBaseStore(nil)
+	require.EqualError(t, err, "genesis trust base is nil")
+	require.Nil(t, store)
+
+	store, err = NewTrustBaseStore(genesis)
+	require.NoError(t, err)
+	tb, err := store.GetTrustBase(1)
+	require.NoError(t, err)
+	require.Equal(t, genesis, tb)
+	_, err = store.GetTrustBase(0)
+	require.ErrorIs(t, err, ErrUnknownEpoch)
+	_, err = store.GetTrustBase(2)
+	require.ErrorIs(t, err, ErrUnknownEpoch)
+
+	signer2, verifier2 := testsig.CreateSignerAndVerifier(t)
+	pubKey2, err := verifier2.MarshalPublicKey()
+	require.NoError(t, err)
+	epoch2, err := types.NextEpoch(genesis, []*types.NodeInfo{{NodeID: "2", SigKey: pubKey2, Stake: 1}}, nil, types.WithEpochStartRound(100))
+	require.NoError(t, err)
+
+	// not signed by the previous epoch
+	require.EqualError(t, store.Add(epoch2), "verifying trust base of epoch 2: quorum not reached, signed_votes=0 quorum_threshold=1")
+	require.NoError(t, epoch2.Sign("2", signer2))
+	require.ErrorContains(t, store.Add(epoch2), "quorum not reached")
+
+	require.NoError(t, epoch2.Sign("1", signer))
+	require.NoError(t, store.Add(epoch2))
+	tb, err = store.GetTrustBase(2)
+	require.NoError(t, err)
+	require.Equal(t, epoch2, tb)
+	require.Equal(t, epoch2, store.Latest())
+
+	require.EqualError(t, store.Add(nil), "trust base is nil")
+}
+
+type (
+	testEnv struct {
+		signer     abcrypto.Signer
+		trustBases *TrustBaseStore
+		pdr        *types.PartitionDescriptionRecord
+	}
+
+	testRound struct {
+		block *types.Block
+		uc    *types.UnicityCertificate
+	}
+)
+
+func newTestEnv(t *testing.T) *testEnv {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	pubKey, err := verifier.MarshalPublicKey()
+	require.NoError(t, err)
+	genesis, err := types.NewTrustBaseGenesis(types.NetworkLocal, []*types.NodeInfo{{NodeID: "test", SigKey: pubKey, Stake: 1}})
+	require.NoError(t, err)
+	store, err := NewTrustBaseStore(genesis)
+	require.NoError(t, err)
+
+	return &testEnv{
+		signer:     signer,
+		trustBases: store,
+		pdr: &types.PartitionDescriptionRecord{
+			Version:         1,
+			NetworkID:       types.NetworkLocal,
+			PartitionID:     7,
+			PartitionTypeID: 1,
+			TypeIDLen:       8,
+			UnitIDLen:       256,
+			T2Timeout:       2500 * time.Millisecond,
+		},
+	}
+}
+
+func (env *testEnv) pdrLookup(partition types.PartitionID, shard types.ShardID, epoch uint64) (*types.PartitionDescriptionRecord, error) {
+	if partition != env.pdr.PartitionID || epoch != 0 {
+		return nil, errors.New("unknown shard")
+	}
+	return env.pdr, nil
+}
+
+func (env *testEnv) newIR(round uint64, prevHash, hash, blockHash []byte) *types.InputRecord {
+	return &types.InputRecord{
+		Version:      1,
+		RoundNumber:  round,
+		PreviousHash: prevHash,
+		Hash:         hash,
+		BlockHash:    blockHash,
+		SummaryValue: util.Uint64ToBytes(0),
+		Timestamp:    types.NewTimestamp(),
+	}
+}
+
+/*
+newRound creates block with single transaction and UC certifying it.
+*/
+func (env *testEnv) newRound(t *testing.T, round, rootRound uint64, prevHash, hash []byte) *testRound {
+	txo := &types.TransactionOrder{
+		Version: 1,
+		Payload: types.Payload{
+			NetworkID:   env.pdr.NetworkID,
+			PartitionID: env.pdr.PartitionID,
+			UnitID:      make(types.UnitID, 33),
+			Type:        1,
+		},
+	}
+	txoBytes, err := txo.MarshalCBOR()
+	require.NoError(t, err)
+	block := &types.Block{
+		Header: &types.Header{
+			Version:     1,
+			PartitionID: env.pdr.PartitionID,
+			ProposerID:  "proposer",
+		},
+		Transactions: []*types.TransactionRecord{{
+			Version:          1,
+			TransactionOrder: txoBytes,
+			ServerMetadata:   &types.ServerMetadata{ActualFee: 1, SuccessIndicator: types.TxStatusSuccessful},
+		}},
+	}
+	blockHash, err := types.BlockHash(crypto.SHA256, block.Header, block.Transactions, hash, prevHash)
+	require.NoError(t, err)
+	uc := testuc.CreateUnicityCertificate(t, "test", env.signer, env.newIR(round, prevHash, hash, blockHash), env.pdr,
+		&types.UnicitySeal{NetworkID: env.pdr.NetworkID, RootChainRoundNumber: rootRound, Epoch: 1, Timestamp: types.NewTimestamp()})
+	block.UnicityCertificate, err = uc.MarshalCBOR()
+	require.NoError(t, err)
+	return &testRound{block: block, uc: uc}
+}
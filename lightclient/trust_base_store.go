@@ -0,0 +1,71 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+/*
+TrustBaseStore keeps the chain of root trust base entries, starting from the
+genesis entry. Each entry added to the store must extend the latest entry and
+be signed by the quorum of the root nodes of the previous epoch.
+*/
+type TrustBaseStore struct {
+	entries []*types.RootTrustBaseV1 // entries[i] is the entry of the epoch genesis.Epoch+i
+	mu      sync.RWMutex
+}
+
+/*
+NewTrustBaseStore returns trust base store initialized with the "genesis" entry.
+The genesis entry is the trust anchor of the light client, it is not verified
+and must come from a trusted source.
+*/
+func NewTrustBaseStore(genesis *types.RootTrustBaseV1) (*TrustBaseStore, error) {
+	if genesis == nil {
+		return nil, errors.New("genesis trust base is nil")
+	}
+	return &TrustBaseStore{entries: []*types.RootTrustBaseV1{genesis}}, nil
+}
+
+/*
+Add verifies that the entry extends the latest entry in the store and appends it.
+*/
+func (s *TrustBaseStore) Add(tb *types.RootTrustBaseV1) error {
+	if tb == nil {
+		return errors.New("trust base is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := s.entries[len(s.entries)-1]
+	if err := tb.Verify(latest); err != nil {
+		return fmt.Errorf("verifying trust base of epoch %d: %w", tb.Epoch, err)
+	}
+	s.entries = append(s.entries, tb)
+	return nil
+}
+
+/*
+GetTrustBase returns the trust base of the epoch, it has the signature
+of the types.TrustBaseLookup.
+*/
+func (s *TrustBaseStore) GetTrustBase(epoch uint64) (types.RootTrustBase, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	first := s.entries[0].Epoch
+	if epoch < first || epoch-first >= uint64(len(s.entries)) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownEpoch, epoch)
+	}
+	return s.entries[epoch-first], nil
+}
+
+// Latest returns the trust base of the latest known epoch.
+func (s *TrustBaseStore) Latest() *types.RootTrustBaseV1 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.entries[len(s.entries)-1]
+}
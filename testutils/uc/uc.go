@@ -0,0 +1,54 @@
+package testuc
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+/*
+CreateUnicityCertificate returns UC certifying the "ir" of the single-shard partition
+described by the "pdr". The "seal" is used as the template of the unicity seal,
+it's Hash field is set and it is signed by the "signer" using "rootID" as
+the signer ID. TRHash of the UC is zero hash.
+*/
+func CreateUnicityCertificate(t *testing.T, rootID string, signer abcrypto.Signer, ir *types.InputRecord, pdr *types.PartitionDescriptionRecord, seal *types.UnicitySeal) *types.UnicityCertificate {
+	t.Helper()
+
+	shardConfHash, err := pdr.Hash(crypto.SHA256)
+	require.NoError(t, err)
+	trHash := make([]byte, 32)
+	sTree, err := types.CreateShardTree(types.ShardingScheme{}, []types.ShardTreeInput{
+		{IR: ir, TRHash: trHash, ShardConfHash: shardConfHash},
+	}, crypto.SHA256)
+	require.NoError(t, err)
+	stCert, err := sTree.Certificate(types.ShardID{})
+	require.NoError(t, err)
+
+	ut, err := types.NewUnicityTree(crypto.SHA256, []*types.UnicityTreeData{{
+		Partition:     pdr.PartitionID,
+		ShardTreeRoot: sTree.RootHash(),
+	}})
+	require.NoError(t, err)
+	utCert, err := ut.Certificate(pdr.PartitionID)
+	require.NoError(t, err)
+
+	seal.Version = 1
+	seal.Hash = ut.RootHash()
+	seal.Signatures = nil
+	require.NoError(t, seal.Sign(rootID, signer))
+
+	return &types.UnicityCertificate{
+		Version:                1,
+		InputRecord:            ir,
+		TRHash:                 trHash,
+		ShardConfHash:          shardConfHash,
+		ShardTreeCertificate:   stCert,
+		UnicityTreeCertificate: utCert,
+		UnicitySeal:            seal,
+	}
+}
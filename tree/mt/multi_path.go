@@ -0,0 +1,119 @@
+package mt
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	abhash "github.com/alphabill-org/alphabill-go-base/hash"
+)
+
+/*
+GetMultiPath returns the hashes of the sub-trees which do not contain any of
+the given leaves but are needed to calculate the root hash from the leaves
+(see EvalMultiPath). Hashes are in the depth-first, left-to-right order of the
+sub-trees. Leaf indexes must be in ascending order without duplicates.
+
+Compared to the merkle paths of the individual leaves the hashes shared by
+the paths are included only once and the hashes which can be calculated from
+the leaves are omitted.
+*/
+func (s *MerkleTree) GetMultiPath(leafIndexes []int) ([][]byte, error) {
+	if err := checkLeafIndexes(s.dataLength, leafIndexes); err != nil {
+		return nil, err
+	}
+	var siblings [][]byte
+	var walk func(n *node, b, m int, idx []int)
+	walk = func(n *node, b, m int, idx []int) {
+		if len(idx) == 0 {
+			siblings = append(siblings, n.hash)
+			return
+		}
+		if m == 1 {
+			return
+		}
+		k := hibit(m - 1)
+		split := splitIndexes(idx, b+k)
+		walk(n.left, b, k, idx[:split])
+		walk(n.right, b+k, m-k, idx[split:])
+	}
+	walk(s.root, 0, s.dataLength, leafIndexes)
+	return siblings, nil
+}
+
+/*
+EvalMultiPath returns the root hash of the tree of "leafCount" leaves calculated
+from the "leaves" at "leafIndexes" and the sub-tree hashes returned by
+MerkleTree.GetMultiPath.
+*/
+func EvalMultiPath[T Data](leafCount int, leafIndexes []int, leaves []T, siblings [][]byte, hashAlgorithm crypto.Hash) ([]byte, error) {
+	if err := checkLeafIndexes(leafCount, leafIndexes); err != nil {
+		return nil, err
+	}
+	if len(leaves) != len(leafIndexes) {
+		return nil, fmt.Errorf("got %d leaves for %d leaf indexes", len(leaves), len(leafIndexes))
+	}
+
+	var eval func(b, m int, idx []int, leaves []T) ([]byte, error)
+	eval = func(b, m int, idx []int, leaves []T) ([]byte, error) {
+		if len(idx) == 0 {
+			if len(siblings) == 0 {
+				return nil, errors.New("not enough sibling hashes")
+			}
+			h := siblings[0]
+			siblings = siblings[1:]
+			return h, nil
+		}
+		if m == 1 {
+			h, err := leaves[0].Hash(hashAlgorithm)
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash leaf: %w", err)
+			}
+			return h, nil
+		}
+		k := hibit(m - 1)
+		split := splitIndexes(idx, b+k)
+		left, err := eval(b, k, idx[:split], leaves[:split])
+		if err != nil {
+			return nil, err
+		}
+		right, err := eval(b+k, m-k, idx[split:], leaves[split:])
+		if err != nil {
+			return nil, err
+		}
+		return abhash.HashValues(hashAlgorithm, left, right)
+	}
+	root, err := eval(0, leafCount, leafIndexes, leaves)
+	if err != nil {
+		return nil, err
+	}
+	if len(siblings) != 0 {
+		return nil, fmt.Errorf("%d unused sibling hashes", len(siblings))
+	}
+	return root, nil
+}
+
+func checkLeafIndexes(leafCount int, leafIndexes []int) error {
+	if len(leafIndexes) == 0 {
+		return errors.New("no leaf indexes")
+	}
+	for i, idx := range leafIndexes {
+		if idx < 0 || idx >= leafCount {
+			return ErrIndexOutOfBounds
+		}
+		if i > 0 && idx <= leafIndexes[i-1] {
+			return fmt.Errorf("leaf indexes must be in ascending order without duplicates, got %d after %d", idx, leafIndexes[i-1])
+		}
+	}
+	return nil
+}
+
+// splitIndexes returns the position of the first index which is >= "bound".
+func splitIndexes(idx []int, bound int) int {
+	for i, v := range idx {
+		if v >= bound {
+			return i
+		}
+	}
+	return len(idx)
+}
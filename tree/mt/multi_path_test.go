@@ -0,0 +1,77 @@
+package mt
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiPathEval(t *testing.T) {
+	tests := []struct {
+		name         string
+		dataLength   int
+		idxToVerify  []int
+		siblingCount int
+	}{
+		{name: "single node tree", dataLength: 1, idxToVerify: []int{0}, siblingCount: 0},
+		{name: "all leaves", dataLength: 5, idxToVerify: []int{0, 1, 2, 3, 4}, siblingCount: 0},
+		{name: "single leaf", dataLength: 8, idxToVerify: []int{5}, siblingCount: 3},
+		{name: "siblings", dataLength: 8, idxToVerify: []int{2, 3}, siblingCount: 2},
+		{name: "shared path", dataLength: 8, idxToVerify: []int{0, 7}, siblingCount: 4},
+		{name: "odd tree", dataLength: 7, idxToVerify: []int{1, 4, 6}, siblingCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data = make([]Data, tt.dataLength)
+			for i := 0; i < len(data); i++ {
+				data[i] = &TestData{hash: makeData(byte(i))}
+			}
+			mt, err := New(crypto.SHA256, data)
+			require.NoError(t, err)
+			siblings, err := mt.GetMultiPath(tt.idxToVerify)
+			require.NoError(t, err)
+			require.Len(t, siblings, tt.siblingCount)
+
+			leaves := make([]Data, len(tt.idxToVerify))
+			for i, idx := range tt.idxToVerify {
+				leaves[i] = data[idx]
+			}
+			rootHash, err := EvalMultiPath(tt.dataLength, tt.idxToVerify, leaves, siblings, crypto.SHA256)
+			require.NoError(t, err)
+			require.Equal(t, mt.GetRootHash(), rootHash)
+
+			// wrong leaf gives different root
+			leaves[0] = &TestData{hash: makeData(100)}
+			rootHash, err = EvalMultiPath(tt.dataLength, tt.idxToVerify, leaves, siblings, crypto.SHA256)
+			require.NoError(t, err)
+			require.NotEqual(t, mt.GetRootHash(), rootHash)
+		})
+	}
+}
+
+func TestMultiPathErrors(t *testing.T) {
+	data := make([]Data, 6)
+	for i := range data {
+		data[i] = &TestData{hash: makeData(byte(i))}
+	}
+	mt, err := New(crypto.SHA256, data)
+	require.NoError(t, err)
+
+	_, err = mt.GetMultiPath(nil)
+	require.EqualError(t, err, "no leaf indexes")
+	_, err = mt.GetMultiPath([]int{6})
+	require.ErrorIs(t, err, ErrIndexOutOfBounds)
+	_, err = mt.GetMultiPath([]int{2, 2})
+	require.EqualError(t, err, "leaf indexes must be in ascending order without duplicates, got 2 after 2")
+
+	siblings, err := mt.GetMultiPath([]int{1, 3})
+	require.NoError(t, err)
+	leaves := []Data{data[1], data[3]}
+	_, err = EvalMultiPath(6, []int{1, 3}, leaves[:1], siblings, crypto.SHA256)
+	require.EqualError(t, err, "got 1 leaves for 2 leaf indexes")
+	_, err = EvalMultiPath(6, []int{1, 3}, leaves, siblings[1:], crypto.SHA256)
+	require.EqualError(t, err, "not enough sibling hashes")
+	_, err = EvalMultiPath(6, []int{1, 3}, leaves, append(siblings, makeData(1)), crypto.SHA256)
+	require.EqualError(t, err, "1 unused sibling hashes")
+}
@@ -0,0 +1,436 @@
+/*
+Package state implements in-memory authenticated state tree of the units of a
+shard. The tree produces types.UnitStateProof which verify against the state
+root hash and summary value calculated by the tree, ie it can be used to test
+proof verifiers and to build mock partitions.
+
+The tree is a balanced (AVL) binary search tree keyed by the unit ID. Each node
+stores the unit and it's log - the list of the states of the unit created in
+the current round. Hash of the node is calculated as expected by the
+types.UnitStateProof.CalculateStateTreeOutput.
+*/
+package state
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	abhash "github.com/alphabill-org/alphabill-go-base/hash"
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
+	"github.com/alphabill-org/alphabill-go-base/types"
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+var (
+	ErrUnitNotFound = errors.New("unit not found")
+	ErrUnitExists   = errors.New("unit already exists")
+)
+
+type (
+	Tree struct {
+		hashAlgorithm crypto.Hash
+		root          *node
+	}
+
+	node struct {
+		key    types.UnitID
+		unit   *Unit
+		left   *node
+		right  *node
+		height int
+
+		// cached values, valid when "clean" is true
+		clean   bool
+		logRoot []byte
+		summary uint64
+		hash    []byte
+	}
+)
+
+// New returns empty state tree.
+func New(hashAlgorithm crypto.Hash) *Tree {
+	return &Tree{hashAlgorithm: hashAlgorithm}
+}
+
+/*
+AddUnit adds new unit to the tree, the first log entry of the unit is not
+created by a transaction.
+*/
+func (t *Tree) AddUnit(id types.UnitID, data types.UnitData) error {
+	if len(id) == 0 {
+		return errors.New("unit ID is empty")
+	}
+	if data == nil {
+		return errors.New("unit data is nil")
+	}
+	unit := &Unit{}
+	if err := unit.addLog(t.hashAlgorithm, nil, data.Copy(), 0, nil); err != nil {
+		return fmt.Errorf("creating unit log: %w", err)
+	}
+	root, err := t.insert(t.root, id, unit)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+/*
+UpdateUnit sets the new state of the unit as the result of executing the
+transaction with record hash "txRecordHash".
+*/
+func (t *Tree) UpdateUnit(id types.UnitID, txRecordHash []byte, data types.UnitData, opts ...UpdateOption) error {
+	if len(txRecordHash) == 0 {
+		return errors.New("transaction record hash is empty")
+	}
+	if data == nil {
+		return errors.New("unit data is nil")
+	}
+	path, err := t.path(id)
+	if err != nil {
+		return err
+	}
+	o := &updateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := path[len(path)-1].unit.addLog(t.hashAlgorithm, txRecordHash, data.Copy(), o.deletionRound, o.stateLockTx); err != nil {
+		return fmt.Errorf("adding unit log: %w", err)
+	}
+	for _, n := range path {
+		n.clean = false
+	}
+	return nil
+}
+
+// Unit returns the unit with given ID, the unit must not be modified.
+func (t *Tree) Unit(id types.UnitID) (*Unit, error) {
+	path, err := t.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return path[len(path)-1].unit, nil
+}
+
+/*
+PruneLogs is called at the end of the round, it removes all but the latest log
+entry of each unit. The latest entry continues the unit's ledger in the next
+round. As the log roots of the units change the root hash of the tree changes
+too, ie the tree must be pruned after the state of the round has been certified.
+*/
+func (t *Tree) PruneLogs() {
+	var prune func(n *node) bool
+	prune = func(n *node) bool {
+		if n == nil {
+			return false
+		}
+		if len(n.unit.logs) > 1 {
+			n.unit.logs = n.unit.logs[len(n.unit.logs)-1:]
+			n.clean = false
+		}
+		// both sub-trees must be pruned
+		l, r := prune(n.left), prune(n.right)
+		if l || r {
+			n.clean = false
+		}
+		return !n.clean
+	}
+	prune(t.root)
+}
+
+/*
+RootHash returns the root hash and the summary value of the tree. For empty
+tree nil hash and zero summary value is returned.
+*/
+func (t *Tree) RootHash() ([]byte, uint64, error) {
+	if err := t.root.calculate(t.hashAlgorithm); err != nil {
+		return nil, 0, err
+	}
+	if t.root == nil {
+		return nil, 0, nil
+	}
+	return t.root.hash, t.root.summary, nil
+}
+
+/*
+UnitStateProof returns proof of the latest state of the unit. The UnicityCertificate
+field of the proof is not assigned, caller must set it to the UC certifying the
+root hash of the tree.
+*/
+func (t *Tree) UnitStateProof(id types.UnitID) (*types.UnitStateProof, error) {
+	if err := t.root.calculate(t.hashAlgorithm); err != nil {
+		return nil, err
+	}
+	path, err := t.path(id)
+	if err != nil {
+		return nil, err
+	}
+	target := path[len(path)-1]
+
+	logs := target.unit.logs
+	latest := logs[len(logs)-1]
+	hashes := make([]logHash, len(logs))
+	for i, l := range logs {
+		hashes[i] = l.Hash
+	}
+	logTree, err := mt.New(t.hashAlgorithm, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("creating unit log tree: %w", err)
+	}
+	logPath, err := logTree.GetMerklePath(len(hashes) - 1)
+	if err != nil {
+		return nil, fmt.Errorf("extracting unit log path: %w", err)
+	}
+
+	return &types.UnitStateProof{
+		Version:        1,
+		UnitID:         id,
+		UnitValue:      target.unit.Data().SummaryValueInput(),
+		UnitLedgerHash: latest.PreviousHash,
+		UnitTreeCert: &types.UnitTreeCert{
+			TransactionRecordHash: latest.TxRecordHash,
+			UnitStateHash:         latest.StateHash,
+			Path:                  logPath,
+		},
+		StateTreeCert: stateTreeCert(path),
+	}, nil
+}
+
+/*
+UnitLogProof returns proof of all the states of the unit in the unit's log. The
+UnicityCertificate field of the proof is not assigned, caller must set it to the
+UC certifying the root hash of the tree.
+*/
+func (t *Tree) UnitLogProof(id types.UnitID) (*types.UnitLogProof, error) {
+	if err := t.root.calculate(t.hashAlgorithm); err != nil {
+		return nil, err
+	}
+	path, err := t.path(id)
+	if err != nil {
+		return nil, err
+	}
+	target := path[len(path)-1]
+
+	logs := target.unit.logs
+	entries := make([]*types.UnitLogEntry, len(logs))
+	for i, l := range logs {
+		entries[i] = &types.UnitLogEntry{
+			TransactionRecordHash: l.TxRecordHash,
+			UnitStateHash:         l.StateHash,
+		}
+	}
+	return &types.UnitLogProof{
+		Version:        1,
+		UnitID:         id,
+		UnitValue:      target.unit.Data().SummaryValueInput(),
+		UnitLedgerHash: logs[0].PreviousHash,
+		Entries:        entries,
+		StateTreeCert:  stateTreeCert(path),
+	}, nil
+}
+
+/*
+UnitAbsenceProof returns proof that the unit doesn't exist in the tree. The
+UnicityCertificate field of the proof is not assigned, caller must set it to the
+UC certifying the root hash of the tree.
+*/
+func (t *Tree) UnitAbsenceProof(id types.UnitID) (*types.UnitAbsenceProof, error) {
+	if err := t.root.calculate(t.hashAlgorithm); err != nil {
+		return nil, err
+	}
+	path, found := t.search(id)
+	if found {
+		return nil, fmt.Errorf("%w: %s", ErrUnitExists, id)
+	}
+	if len(path) == 0 {
+		return nil, errors.New("proof of absence can't be created for empty tree")
+	}
+	n := path[len(path)-1]
+	return &types.UnitAbsenceProof{
+		Version:       1,
+		UnitID:        id,
+		NodeUnitID:    n.key,
+		NodeLogsHash:  n.logRoot,
+		NodeValue:     n.unit.Data().SummaryValueInput(),
+		StateTreeCert: stateTreeCert(path),
+	}, nil
+}
+
+/*
+stateTreeCert returns the state tree certificate of the last node of the path,
+the path must start from the root of the tree and the hashes must be calculated.
+*/
+func stateTreeCert(path []*node) *types.StateTreeCert {
+	target := path[len(path)-1]
+	cert := &types.StateTreeCert{
+		LeftSummaryHash:   target.left.getHash(),
+		LeftSummaryValue:  target.left.getSummary(),
+		RightSummaryHash:  target.right.getHash(),
+		RightSummaryValue: target.right.getSummary(),
+	}
+	for i := len(path) - 2; i >= 0; i-- {
+		p := path[i]
+		sibling := p.left
+		if sibling == path[i+1] {
+			sibling = p.right
+		}
+		cert.Path = append(cert.Path, &types.StateTreePathItem{
+			UnitID:              p.key,
+			LogsHash:            p.logRoot,
+			Value:               p.unit.Data().SummaryValueInput(),
+			SiblingSummaryHash:  sibling.getHash(),
+			SiblingSummaryValue: sibling.getSummary(),
+		})
+	}
+	return cert
+}
+
+// path returns nodes from the root to the node of the unit.
+func (t *Tree) path(id types.UnitID) ([]*node, error) {
+	path, found := t.search(id)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnitNotFound, id)
+	}
+	return path, nil
+}
+
+/*
+search returns nodes visited by the search of the unit, when the unit is not
+found the last node of the path is the node where the unit would be inserted
+as a child.
+*/
+func (t *Tree) search(id types.UnitID) (path []*node, found bool) {
+	for n := t.root; n != nil; {
+		path = append(path, n)
+		switch c := id.Compare(n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return path, true
+		}
+	}
+	return path, false
+}
+
+func (t *Tree) insert(n *node, id types.UnitID, unit *Unit) (*node, error) {
+	if n == nil {
+		return &node{key: id, unit: unit, height: 1}, nil
+	}
+	var err error
+	switch c := id.Compare(n.key); {
+	case c < 0:
+		n.left, err = t.insert(n.left, id, unit)
+	case c > 0:
+		n.right, err = t.insert(n.right, id, unit)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnitExists, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	n.clean = false
+	return n.rebalance(), nil
+}
+
+func (n *node) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *node) getHash() []byte {
+	if n == nil {
+		return nil
+	}
+	return n.hash
+}
+
+func (n *node) getSummary() uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.summary
+}
+
+func (n *node) updateHeight() {
+	n.height = 1 + max(n.left.getHeight(), n.right.getHeight())
+}
+
+func (n *node) rebalance() *node {
+	n.updateHeight()
+	switch balance := n.left.getHeight() - n.right.getHeight(); {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *node) rotateLeft() *node {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.clean, r.clean = false, false
+	n.updateHeight()
+	r.updateHeight()
+	return r
+}
+
+func (n *node) rotateRight() *node {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.clean, l.clean = false, false
+	n.updateHeight()
+	l.updateHeight()
+	return l
+}
+
+// calculate recalculates the cached hashes of the sub-tree.
+func (n *node) calculate(hashAlgorithm crypto.Hash) error {
+	if n == nil || n.clean {
+		return nil
+	}
+	if err := n.left.calculate(hashAlgorithm); err != nil {
+		return err
+	}
+	if err := n.right.calculate(hashAlgorithm); err != nil {
+		return err
+	}
+
+	logRoot, err := n.unit.logRoot(hashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("calculating log root of unit %s: %w", n.key, err)
+	}
+	n.logRoot = logRoot
+	summary, ok := util.AddUint64(n.unit.Data().SummaryValueInput(), n.left.getSummary(), n.right.getSummary())
+	if !ok {
+		return fmt.Errorf("summary value of unit %s overflows uint64", n.key)
+	}
+	n.summary = summary
+
+	hasher := abhash.New(hashAlgorithm.New())
+	hasher.Write(n.key)
+	hasher.Write(n.logRoot)
+	hasher.Write(n.summary)
+	hasher.Write(n.left.getHash())
+	hasher.Write(n.left.getSummary())
+	hasher.Write(n.right.getHash())
+	hasher.Write(n.right.getSummary())
+	if n.hash, err = hasher.Sum(); err != nil {
+		return fmt.Errorf("calculating hash of unit %s: %w", n.key, err)
+	}
+	n.clean = true
+	return nil
+}
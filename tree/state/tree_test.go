@@ -0,0 +1,244 @@
+package state
+
+import (
+	"crypto"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alphabill-org/alphabill-go-base/txsystem/money"
+	"github.com/alphabill-org/alphabill-go-base/types"
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+type ucValidator struct{}
+
+func (ucValidator) Validate(*types.UnicityCertificate, []byte) error { return nil }
+
+func unitID(i uint64) types.UnitID {
+	return append(make(types.UnitID, 25), util.Uint64ToBytes(i)...)
+}
+
+/*
+verifyProofs checks that the proof of each unit verifies against the root hash
+and summary value of the tree.
+*/
+func verifyProofs(t *testing.T, tree *Tree, ids ...types.UnitID) {
+	t.Helper()
+	rootHash, summary, err := tree.RootHash()
+	require.NoError(t, err)
+	uc, err := (&types.UnicityCertificate{
+		Version:     1,
+		InputRecord: &types.InputRecord{Version: 1, Hash: rootHash, SummaryValue: util.Uint64ToBytes(summary)},
+	}).MarshalCBOR()
+	require.NoError(t, err)
+
+	for _, id := range ids {
+		proof, err := tree.UnitStateProof(id)
+		require.NoError(t, err)
+		proof.UnicityCertificate = uc
+		unit, err := tree.Unit(id)
+		require.NoError(t, err)
+		require.NoError(t, proof.Verify(crypto.SHA256, unit.State(), ucValidator{}, nil), "unit %s", id)
+
+		// proof survives serialization
+		data, err := types.Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &types.UnitStateProof{}
+		require.NoError(t, types.Cbor.Unmarshal(data, proof2))
+		require.NoError(t, proof2.Verify(crypto.SHA256, unit.State(), ucValidator{}, nil), "unit %s", id)
+
+		// log proof proves all the states of the unit
+		logProof, err := tree.UnitLogProof(id)
+		require.NoError(t, err)
+		logProof.UnicityCertificate = uc
+		var states []*types.UnitState
+		for _, l := range unit.Logs() {
+			states = append(states, l.State)
+		}
+		require.NoError(t, logProof.Verify(crypto.SHA256, states, ucValidator{}, nil), "unit %s", id)
+	}
+}
+
+func TestTree(t *testing.T) {
+	t.Run("empty tree", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		h, v, err := tree.RootHash()
+		require.NoError(t, err)
+		require.Nil(t, h)
+		require.Zero(t, v)
+		_, err = tree.UnitStateProof(unitID(1))
+		require.ErrorIs(t, err, ErrUnitNotFound)
+	})
+
+	t.Run("single unit", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		require.NoError(t, tree.AddUnit(unitID(1), money.NewBillData(10, []byte{1})))
+		_, v, err := tree.RootHash()
+		require.NoError(t, err)
+		require.EqualValues(t, 10, v)
+		verifyProofs(t, tree, unitID(1))
+
+		proof, err := tree.UnitStateProof(unitID(1))
+		require.NoError(t, err)
+		require.Nil(t, proof.UnitLedgerHash)
+		require.Nil(t, proof.UnitTreeCert.TransactionRecordHash)
+		require.Empty(t, proof.StateTreeCert.Path)
+	})
+
+	t.Run("units and logs", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		var ids []types.UnitID
+		var total uint64
+		for _, i := range rand.Perm(100) {
+			id := unitID(uint64(i))
+			ids = append(ids, id)
+			total += uint64(i)
+			require.NoError(t, tree.AddUnit(id, money.NewBillData(uint64(i), []byte{byte(i)})))
+		}
+		// AVL tree height is at most 1.44*log2(n+2)
+		require.LessOrEqual(t, float64(tree.root.height), 1.44*math.Log2(102))
+		_, v, err := tree.RootHash()
+		require.NoError(t, err)
+		require.Equal(t, total, v)
+		verifyProofs(t, tree, ids...)
+
+		// several transactions modify the same unit in the round
+		for round := range 3 {
+			bill := money.NewBillData(uint64(100+round), []byte{byte(round)})
+			require.NoError(t, tree.UpdateUnit(ids[7], []byte(fmt.Sprintf("tx %d", round)), bill))
+		}
+		require.NoError(t, tree.UpdateUnit(ids[8], []byte("tx"), money.NewBillData(1, nil), WithDeletionRound(10), WithStateLock([]byte{0x80})))
+		unit, err := tree.Unit(ids[8])
+		require.NoError(t, err)
+		require.EqualValues(t, 10, unit.State().DeletionRound)
+		require.EqualValues(t, []byte{0x80}, unit.State().StateLockTx)
+
+		unit, err = tree.Unit(ids[7])
+		require.NoError(t, err)
+		require.Len(t, unit.Logs(), 4)
+		require.EqualValues(t, 102, unit.Data().SummaryValueInput())
+		verifyProofs(t, tree, ids...)
+
+		proof, err := tree.UnitStateProof(ids[7])
+		require.NoError(t, err)
+		require.Equal(t, []byte("tx 2"), []byte(proof.UnitTreeCert.TransactionRecordHash))
+		require.Equal(t, unit.Logs()[2].Hash, []byte(proof.UnitLedgerHash))
+
+		logProof, err := tree.UnitLogProof(ids[7])
+		require.NoError(t, err)
+		require.Len(t, logProof.Entries, 4)
+		require.Nil(t, logProof.UnitLedgerHash)
+		require.Nil(t, logProof.Entries[0].TransactionRecordHash)
+		require.Equal(t, []byte("tx 0"), []byte(logProof.Entries[1].TransactionRecordHash))
+
+		// pruning logs keeps the latest state of the units
+		rootHash, summary, err := tree.RootHash()
+		require.NoError(t, err)
+		tree.PruneLogs()
+		unit, err = tree.Unit(ids[7])
+		require.NoError(t, err)
+		require.Len(t, unit.Logs(), 1)
+		require.EqualValues(t, 102, unit.Data().SummaryValueInput())
+		rootHash2, summary2, err := tree.RootHash()
+		require.NoError(t, err)
+		require.NotEqual(t, rootHash, rootHash2)
+		require.Equal(t, summary, summary2)
+		verifyProofs(t, tree, ids...)
+
+		// pruning tree without multi-entry logs doesn't change it
+		tree.PruneLogs()
+		rootHash3, _, err := tree.RootHash()
+		require.NoError(t, err)
+		require.Equal(t, rootHash2, rootHash3)
+	})
+
+	t.Run("proof of other state fails", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		require.NoError(t, tree.AddUnit(unitID(1), money.NewBillData(10, nil)))
+		require.NoError(t, tree.AddUnit(unitID(2), money.NewBillData(20, nil)))
+		unit, err := tree.Unit(unitID(1))
+		require.NoError(t, err)
+		oldState := unit.State()
+		require.NoError(t, tree.UpdateUnit(unitID(1), []byte{1}, money.NewBillData(5, nil)))
+
+		rootHash, summary, err := tree.RootHash()
+		require.NoError(t, err)
+		require.EqualValues(t, 25, summary)
+		proof, err := tree.UnitStateProof(unitID(1))
+		require.NoError(t, err)
+		proof.UnicityCertificate, err = (&types.UnicityCertificate{
+			Version:     1,
+			InputRecord: &types.InputRecord{Version: 1, Hash: rootHash, SummaryValue: util.Uint64ToBytes(summary)},
+		}).MarshalCBOR()
+		require.NoError(t, err)
+		require.EqualError(t, proof.Verify(crypto.SHA256, oldState, ucValidator{}, nil), "unit state hash does not match unit state hash in unit tree cert")
+
+		logProof, err := tree.UnitLogProof(unitID(1))
+		require.NoError(t, err)
+		logProof.UnicityCertificate = proof.UnicityCertificate
+		require.NoError(t, logProof.Verify(crypto.SHA256, []*types.UnitState{oldState, unit.State()}, ucValidator{}, nil))
+		require.EqualError(t, logProof.Verify(crypto.SHA256, []*types.UnitState{unit.State(), oldState}, ucValidator{}, nil), "unit state 0 hash does not match unit state hash of the log entry")
+		require.EqualError(t, logProof.Verify(crypto.SHA256, []*types.UnitState{unit.State()}, ucValidator{}, nil), "expected 2 unit states, got 1")
+	})
+
+	t.Run("absence proofs", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		_, err := tree.UnitAbsenceProof(unitID(1))
+		require.EqualError(t, err, "proof of absence can't be created for empty tree")
+
+		// units with even IDs exist
+		for _, i := range rand.Perm(50) {
+			require.NoError(t, tree.AddUnit(unitID(uint64(2*i+2)), money.NewBillData(uint64(i), nil)))
+		}
+		rootHash, summary, err := tree.RootHash()
+		require.NoError(t, err)
+		uc, err := (&types.UnicityCertificate{
+			Version:     1,
+			InputRecord: &types.InputRecord{Version: 1, Hash: rootHash, SummaryValue: util.Uint64ToBytes(summary)},
+		}).MarshalCBOR()
+		require.NoError(t, err)
+		proofs := map[uint64]*types.UnitAbsenceProof{}
+		for i := uint64(1); i <= 103; i += 2 {
+			proof, err := tree.UnitAbsenceProof(unitID(i))
+			require.NoError(t, err)
+			proof.UnicityCertificate = uc
+			require.NoError(t, proof.Verify(crypto.SHA256, ucValidator{}, nil), "unit %d", i)
+			proofs[i] = proof
+		}
+		_, err = tree.UnitAbsenceProof(unitID(2))
+		require.ErrorIs(t, err, ErrUnitExists)
+
+		// proof of other unit ID doesn't prove absence of the unit
+		proof := proofs[1]
+		proof.UnitID = unitID(2)
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucValidator{}, nil), "unit 000000000000000000000000000000000000000000000000000000000000000002 exists in the state tree")
+		proof.UnitID = unitID(51)
+		require.Error(t, proof.Verify(crypto.SHA256, ucValidator{}, nil))
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		require.EqualError(t, tree.AddUnit(nil, money.NewBillData(1, nil)), "unit ID is empty")
+		require.EqualError(t, tree.AddUnit(unitID(1), nil), "unit data is nil")
+		require.NoError(t, tree.AddUnit(unitID(1), money.NewBillData(1, nil)))
+		require.ErrorIs(t, tree.AddUnit(unitID(1), money.NewBillData(1, nil)), ErrUnitExists)
+
+		require.EqualError(t, tree.UpdateUnit(unitID(1), nil, money.NewBillData(1, nil)), "transaction record hash is empty")
+		require.EqualError(t, tree.UpdateUnit(unitID(1), []byte{1}, nil), "unit data is nil")
+		require.ErrorIs(t, tree.UpdateUnit(unitID(2), []byte{1}, money.NewBillData(1, nil)), ErrUnitNotFound)
+		_, err := tree.Unit(unitID(2))
+		require.ErrorIs(t, err, ErrUnitNotFound)
+	})
+
+	t.Run("summary value overflow", func(t *testing.T) {
+		tree := New(crypto.SHA256)
+		require.NoError(t, tree.AddUnit(unitID(1), money.NewBillData(math.MaxUint64, nil)))
+		require.NoError(t, tree.AddUnit(unitID(2), money.NewBillData(1, nil)))
+		_, _, err := tree.RootHash()
+		require.ErrorContains(t, err, "overflows uint64")
+	})
+}
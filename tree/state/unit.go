@@ -0,0 +1,117 @@
+package state
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+type (
+	// Unit is the unit stored in the state tree.
+	Unit struct {
+		logs []*Log
+	}
+
+	/*
+		Log is the entry of the unit's ledger, it describes the state of the unit
+		created by the transaction.
+	*/
+	Log struct {
+		PreviousHash []byte           // head of the unit's ledger before the entry, nil for the first entry of the unit
+		TxRecordHash []byte           // hash of the transaction which created the state, nil when the state was not created by transaction
+		State        *types.UnitState // state of the unit
+		StateHash    []byte           // hash of the State
+		Hash         []byte           // head of the unit's ledger after the entry
+		data         types.UnitData
+	}
+
+	UpdateOption func(o *updateOptions)
+
+	updateOptions struct {
+		deletionRound uint64
+		stateLockTx   types.RawCBOR
+	}
+
+	// logHash is the leaf of the unit log tree.
+	logHash []byte
+)
+
+// WithDeletionRound sets the deletion round of the new state of the unit.
+func WithDeletionRound(round uint64) UpdateOption {
+	return func(o *updateOptions) {
+		o.deletionRound = round
+	}
+}
+
+// WithStateLock sets the state lock transaction of the new state of the unit.
+func WithStateLock(tx types.RawCBOR) UpdateOption {
+	return func(o *updateOptions) {
+		o.stateLockTx = tx
+	}
+}
+
+// Data returns the data of the latest state of the unit.
+func (u *Unit) Data() types.UnitData {
+	return u.latest().data
+}
+
+// State returns the latest state of the unit.
+func (u *Unit) State() *types.UnitState {
+	return u.latest().State
+}
+
+// Logs returns the log entries of the unit, the latest entry is the last.
+func (u *Unit) Logs() []*Log {
+	return append([]*Log(nil), u.logs...)
+}
+
+func (u *Unit) latest() *Log {
+	return u.logs[len(u.logs)-1]
+}
+
+func (u *Unit) addLog(hashAlgorithm crypto.Hash, txRecordHash []byte, data types.UnitData, deletionRound uint64, stateLockTx types.RawCBOR) error {
+	state, err := types.NewUnitState(data, deletionRound, stateLockTx)
+	if err != nil {
+		return err
+	}
+	stateHash, err := state.Hash(hashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("hashing unit state: %w", err)
+	}
+	var prevHash []byte
+	if len(u.logs) > 0 {
+		prevHash = u.latest().Hash
+	}
+	h, err := types.UnitLedgerHash(hashAlgorithm, prevHash, txRecordHash, stateHash)
+	if err != nil {
+		return fmt.Errorf("hashing log entry: %w", err)
+	}
+	u.logs = append(u.logs, &Log{
+		PreviousHash: prevHash,
+		TxRecordHash: txRecordHash,
+		State:        state,
+		StateHash:    stateHash,
+		Hash:         h,
+		data:         data,
+	})
+	return nil
+}
+
+// logRoot returns the root hash of the Merkle tree of the log entries of the unit.
+func (u *Unit) logRoot(hashAlgorithm crypto.Hash) ([]byte, error) {
+	hashes := make([]logHash, len(u.logs))
+	for i, l := range u.logs {
+		hashes[i] = l.Hash
+	}
+	tree, err := mt.New(hashAlgorithm, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return tree.GetRootHash(), nil
+}
+
+func (h logHash) Hash(crypto.Hash) ([]byte, error) {
+	return h, nil
+}
@@ -0,0 +1,192 @@
+/*
+Package registry maps partition types to the unit data types of the partition,
+ie it allows to decode the unit state of any known partition without knowing
+the Go type of the unit data beforehand.
+*/
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/alphabill-org/alphabill-go-base/txsystem/money"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/orchestration"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/tokens"
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+var ErrUnknownPartitionType = errors.New("unknown partition type")
+
+/*
+UnitDataFactory returns empty unit data of the type of the unit "unitID" of the
+partition described by "pdr".
+*/
+type UnitDataFactory func(unitID types.UnitID, pdr *types.PartitionDescriptionRecord) (types.UnitData, error)
+
+type partition struct {
+	newUnitData UnitDataFactory
+	unitTypes   map[uint32]string // names of the unit types, used as JSON type discriminator
+}
+
+var (
+	partitionsLock sync.RWMutex
+	partitions     = map[types.PartitionTypeID]partition{
+		money.PartitionTypeID: {
+			newUnitData: money.NewUnitData,
+			unitTypes: map[uint32]string{
+				money.BillUnitType:            "bill",
+				money.FeeCreditRecordUnitType: "feeCreditRecord",
+			},
+		},
+		tokens.PartitionTypeID: {
+			newUnitData: tokens.NewUnitData,
+			unitTypes: map[uint32]string{
+				tokens.FungibleTokenTypeUnitType:    "fungibleTokenType",
+				tokens.NonFungibleTokenTypeUnitType: "nonFungibleTokenType",
+				tokens.FungibleTokenUnitType:        "fungibleToken",
+				tokens.NonFungibleTokenUnitType:     "nonFungibleToken",
+				tokens.FeeCreditRecordUnitType:      "feeCreditRecord",
+			},
+		},
+		orchestration.PartitionTypeID: {
+			newUnitData: orchestration.NewUnitData,
+			unitTypes: map[uint32]string{
+				orchestration.VarUnitType: "validatorAssignmentRecord",
+			},
+		},
+	}
+)
+
+/*
+Register adds unit data factory of the partition type. The "unitTypes" maps the
+unit types of the partition to their names, the names are part of the API (see
+UnitDataJSON) and must not change. Factories of the partition types implemented
+in this module are registered by default.
+*/
+func Register(partitionType types.PartitionTypeID, factory UnitDataFactory, unitTypes map[uint32]string) error {
+	if factory == nil {
+		return errors.New("unit data factory is nil")
+	}
+	for unitType, name := range unitTypes {
+		if name == "" {
+			return fmt.Errorf("name of the unit type %d is empty", unitType)
+		}
+	}
+	partitionsLock.Lock()
+	defer partitionsLock.Unlock()
+
+	if _, ok := partitions[partitionType]; ok {
+		return fmt.Errorf("unit data factory of the partition type %d is already registered", partitionType)
+	}
+	partitions[partitionType] = partition{newUnitData: factory, unitTypes: maps.Clone(unitTypes)}
+	return nil
+}
+
+func getPartition(pdr *types.PartitionDescriptionRecord) (partition, error) {
+	if pdr == nil {
+		return partition{}, errors.New("partition description record is nil")
+	}
+	partitionsLock.RLock()
+	p, ok := partitions[pdr.PartitionTypeID]
+	partitionsLock.RUnlock()
+	if !ok {
+		return partition{}, fmt.Errorf("%w: %d", ErrUnknownPartitionType, pdr.PartitionTypeID)
+	}
+	return p, nil
+}
+
+/*
+NewUnitData returns empty unit data of the type of the unit "unitID", the
+factory is selected by the partition type of the "pdr".
+*/
+func NewUnitData(unitID types.UnitID, pdr *types.PartitionDescriptionRecord) (types.UnitData, error) {
+	p, err := getPartition(pdr)
+	if err != nil {
+		return nil, err
+	}
+	return p.newUnitData(unitID, pdr)
+}
+
+// DecodeUnitData returns the data of the unit "unitID" decoded from the unit state.
+func DecodeUnitData(unitID types.UnitID, state *types.UnitState, pdr *types.PartitionDescriptionRecord) (types.UnitData, error) {
+	if state == nil {
+		return nil, errors.New("unit state is nil")
+	}
+	data, err := NewUnitData(unitID, pdr)
+	if err != nil {
+		return nil, fmt.Errorf("creating unit data: %w", err)
+	}
+	if err := state.UnmarshalData(data); err != nil {
+		return nil, fmt.Errorf("decoding unit data: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeUnitStateWithProof returns the unit data of the unit state with proof.
+func DecodeUnitStateWithProof(up *types.UnitStateWithProof, pdr *types.PartitionDescriptionRecord) (types.UnitData, error) {
+	if up == nil {
+		return nil, errors.New("unit state with proof is nil")
+	}
+	if up.Proof == nil {
+		return nil, errors.New("unit state proof is nil")
+	}
+	return DecodeUnitData(up.Proof.UnitID, up.State, pdr)
+}
+
+/*
+UnitDataJSON is the JSON representation of the unit data for API responses, the
+type of the data is described by the partition type and unit type, the Type is
+the registered name of the unit type (ie "bill").
+*/
+type UnitDataJSON struct {
+	UnitID          types.UnitID          `json:"unitId"`
+	PartitionTypeID types.PartitionTypeID `json:"partitionTypeId"`
+	UnitType        uint32                `json:"unitType"`
+	Type            string                `json:"type"`
+	Data            types.UnitData        `json:"data"`
+}
+
+// NewUnitDataJSON returns the JSON representation of the unit data.
+func NewUnitDataJSON(unitID types.UnitID, data types.UnitData, pdr *types.PartitionDescriptionRecord) (*UnitDataJSON, error) {
+	if data == nil {
+		return nil, errors.New("unit data is nil")
+	}
+	p, err := getPartition(pdr)
+	if err != nil {
+		return nil, err
+	}
+	unitType, err := pdr.ExtractUnitType(unitID)
+	if err != nil {
+		return nil, fmt.Errorf("extracting unit type: %w", err)
+	}
+	name, ok := p.unitTypes[unitType]
+	if !ok {
+		return nil, fmt.Errorf("unit type %d of the partition type %d has no registered name", unitType, pdr.PartitionTypeID)
+	}
+	return &UnitDataJSON{
+		UnitID:          unitID,
+		PartitionTypeID: pdr.PartitionTypeID,
+		UnitType:        unitType,
+		Type:            name,
+		Data:            data,
+	}, nil
+}
+
+/*
+MarshalUnitStateJSON decodes the unit data from the unit state and returns it's
+JSON representation.
+*/
+func MarshalUnitStateJSON(unitID types.UnitID, state *types.UnitState, pdr *types.PartitionDescriptionRecord) ([]byte, error) {
+	data, err := DecodeUnitData(unitID, state, pdr)
+	if err != nil {
+		return nil, err
+	}
+	u, err := NewUnitDataJSON(unitID, data, pdr)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(u)
+}
@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	testmoney "github.com/alphabill-org/alphabill-go-base/testutils/money"
+	testorchestration "github.com/alphabill-org/alphabill-go-base/testutils/orchestration"
+	testtokens "github.com/alphabill-org/alphabill-go-base/testutils/tokens"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/fc"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/money"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/orchestration"
+	"github.com/alphabill-org/alphabill-go-base/txsystem/tokens"
+	"github.com/alphabill-org/alphabill-go-base/types"
+)
+
+func TestNewUnitData(t *testing.T) {
+	moneyPDR, tokensPDR, orchestrationPDR := testmoney.PDR(), testtokens.PDR(), testorchestration.PDR()
+
+	var testCases = []struct {
+		unitID types.UnitID
+		pdr    *types.PartitionDescriptionRecord
+		data   types.UnitData
+	}{
+		{unitID: testmoney.NewBillID(t), pdr: &moneyPDR, data: &money.BillData{}},
+		{unitID: testmoney.NewFeeCreditRecordID(t), pdr: &moneyPDR, data: &fc.FeeCreditRecord{}},
+		{unitID: testtokens.NewFungibleTokenTypeID(t), pdr: &tokensPDR, data: &tokens.FungibleTokenTypeData{}},
+		{unitID: testtokens.NewFungibleTokenID(t), pdr: &tokensPDR, data: &tokens.FungibleTokenData{}},
+		{unitID: testtokens.NewNonFungibleTokenTypeID(t), pdr: &tokensPDR, data: &tokens.NonFungibleTokenTypeData{}},
+		{unitID: testtokens.NewNonFungibleTokenID(t), pdr: &tokensPDR, data: &tokens.NonFungibleTokenData{}},
+		{unitID: testtokens.NewFeeCreditRecordID(t), pdr: &tokensPDR, data: &fc.FeeCreditRecord{}},
+		{unitID: testorchestration.NewVarID(t), pdr: &orchestrationPDR, data: &orchestration.VarData{}},
+	}
+	for _, tc := range testCases {
+		data, err := NewUnitData(tc.unitID, tc.pdr)
+		require.NoError(t, err)
+		require.IsType(t, tc.data, data)
+	}
+
+	// unknown unit type of known partition
+	unitID, err := moneyPDR.ComposeUnitID(types.ShardID{}, 0xFF, testmoney.Random)
+	require.NoError(t, err)
+	_, err = NewUnitData(unitID, &moneyPDR)
+	require.ErrorContains(t, err, "unknown unit type in UnitID")
+
+	// unknown partition type
+	pdr := moneyPDR
+	pdr.PartitionTypeID = 0xFFFF
+	_, err = NewUnitData(testmoney.NewBillID(t), &pdr)
+	require.ErrorIs(t, err, ErrUnknownPartitionType)
+
+	_, err = NewUnitData(testmoney.NewBillID(t), nil)
+	require.EqualError(t, err, "partition description record is nil")
+}
+
+func TestRegister(t *testing.T) {
+	const partitionType types.PartitionTypeID = 0xABCD
+	pdr := testmoney.PDR()
+	pdr.PartitionTypeID = partitionType
+
+	factory := func(types.UnitID, *types.PartitionDescriptionRecord) (types.UnitData, error) {
+		return &orchestration.VarData{}, nil
+	}
+	require.EqualError(t, Register(partitionType, nil, nil), "unit data factory is nil")
+	require.EqualError(t, Register(partitionType, factory, map[uint32]string{1: ""}), "name of the unit type 1 is empty")
+	require.EqualError(t, Register(money.PartitionTypeID, money.NewUnitData, nil), "unit data factory of the partition type 1 is already registered")
+
+	require.NoError(t, Register(partitionType, factory, map[uint32]string{money.BillUnitType: "var"}))
+	t.Cleanup(func() {
+		partitionsLock.Lock()
+		delete(partitions, partitionType)
+		partitionsLock.Unlock()
+	})
+	unitID := testmoney.NewBillID(t)
+	data, err := NewUnitData(unitID, &pdr)
+	require.NoError(t, err)
+	require.IsType(t, &orchestration.VarData{}, data)
+
+	u, err := NewUnitDataJSON(unitID, data, &pdr)
+	require.NoError(t, err)
+	require.Equal(t, "var", u.Type)
+	_, err = NewUnitDataJSON(testmoney.NewFeeCreditRecordID(t), data, &pdr)
+	require.EqualError(t, err, "unit type 16 of the partition type 43981 has no registered name")
+}
+
+func TestDecodeUnitData(t *testing.T) {
+	pdr := testmoney.PDR()
+	unitID := testmoney.NewBillID(t)
+	bill := &money.BillData{Version: 1, Value: 10, OwnerPredicate: []byte{1}, Counter: 2}
+	state, err := types.NewUnitState(bill, 0, nil)
+	require.NoError(t, err)
+
+	data, err := DecodeUnitData(unitID, state, &pdr)
+	require.NoError(t, err)
+	require.Equal(t, bill, data)
+
+	data, err = DecodeUnitStateWithProof(&types.UnitStateWithProof{State: state, Proof: &types.UnitStateProof{UnitID: unitID}}, &pdr)
+	require.NoError(t, err)
+	require.Equal(t, bill, data)
+
+	// unit ID says the unit is fee credit record
+	_, err = DecodeUnitData(testmoney.NewFeeCreditRecordID(t), state, &pdr)
+	require.ErrorContains(t, err, "decoding unit data")
+
+	_, err = DecodeUnitData(unitID, nil, &pdr)
+	require.EqualError(t, err, "unit state is nil")
+	_, err = DecodeUnitStateWithProof(nil, &pdr)
+	require.EqualError(t, err, "unit state with proof is nil")
+	_, err = DecodeUnitStateWithProof(&types.UnitStateWithProof{State: state}, &pdr)
+	require.EqualError(t, err, "unit state proof is nil")
+}
+
+func TestUnitDataJSON(t *testing.T) {
+	pdr := testtokens.PDR()
+	unitID := testtokens.NewFungibleTokenID(t)
+	token := &tokens.FungibleTokenData{Version: 1, TypeID: []byte{1}, Value: 5, OwnerPredicate: []byte{2}}
+	state, err := types.NewUnitState(token, 0, nil)
+	require.NoError(t, err)
+
+	b, err := MarshalUnitStateJSON(unitID, state, &pdr)
+	require.NoError(t, err)
+	var v struct {
+		UnitID          types.UnitID             `json:"unitId"`
+		PartitionTypeID types.PartitionTypeID    `json:"partitionTypeId"`
+		UnitType        uint32                   `json:"unitType"`
+		Type            string                   `json:"type"`
+		Data            tokens.FungibleTokenData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(b, &v))
+	require.Equal(t, unitID, v.UnitID)
+	require.Equal(t, tokens.PartitionTypeID, v.PartitionTypeID)
+	require.EqualValues(t, tokens.FungibleTokenUnitType, v.UnitType)
+	require.Equal(t, "fungibleToken", v.Type)
+	require.Equal(t, token, &v.Data)
+
+	_, err = NewUnitDataJSON(unitID, nil, &pdr)
+	require.EqualError(t, err, "unit data is nil")
+	_, err = NewUnitDataJSON(unitID, token, nil)
+	require.EqualError(t, err, "partition description record is nil")
+}
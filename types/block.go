@@ -9,6 +9,7 @@ import (
 	abhash "github.com/alphabill-org/alphabill-go-base/hash"
 	"github.com/alphabill-org/alphabill-go-base/tree/mt"
 	"github.com/alphabill-org/alphabill-go-base/types/hex"
+	"github.com/alphabill-org/alphabill-go-base/util"
 )
 
 var (
@@ -35,8 +36,25 @@ type (
 		ProposerID        string
 		PreviousBlockHash hex.Bytes
 	}
+
+	BlockValidationOption func(o *blockValidationOptions)
+
+	blockValidationOptions struct {
+		checkExecutedTxs bool
+	}
 )
 
+/*
+WithExecutedTxsCheck makes the block validation to check that the executed
+transactions hash and the sum of earned fees in the UC's input record match
+the values calculated from the block's transactions.
+*/
+func WithExecutedTxsCheck() BlockValidationOption {
+	return func(o *blockValidationOptions) {
+		o.checkExecutedTxs = true
+	}
+}
+
 func (b *Block) getUCv1() (*UnicityCertificate, error) {
 	if b == nil {
 		return nil, errBlockIsNil
@@ -110,6 +128,67 @@ func BlockHash(algorithm crypto.Hash, h *Header, txs []*TransactionRecord, state
 	return hasher.Sum()
 }
 
+/*
+ExecutedTransactionsHash returns the executed transactions hash (InputRecord.ETHash)
+of the transactions, it's the hash of the concatenated hashes of the transaction
+records in the order of execution. In case of no transactions ⊥ (nil) is returned.
+*/
+func ExecutedTransactionsHash(algorithm crypto.Hash, txs []*TransactionRecord) ([]byte, error) {
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	hasher := abhash.New(algorithm.New())
+	for i, tx := range txs {
+		if tx == nil {
+			return nil, fmt.Errorf("transaction record %d is nil", i)
+		}
+		h, err := tx.Hash(algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("hashing transaction record %d: %w", i, err)
+		}
+		hasher.WriteRaw(h)
+	}
+	return hasher.Sum()
+}
+
+/*
+SumOfEarnedFees returns the sum of the actual fees of the transactions
+(InputRecord.SumOfEarnedFees).
+*/
+func SumOfEarnedFees(txs []*TransactionRecord) (uint64, error) {
+	fees := make([]uint64, len(txs))
+	for i, tx := range txs {
+		if tx == nil {
+			return 0, fmt.Errorf("transaction record %d is nil", i)
+		}
+		if tx.ServerMetadata == nil {
+			return 0, fmt.Errorf("transaction record %d: server metadata is nil", i)
+		}
+		fees[i] = tx.ServerMetadata.ActualFee
+	}
+	sum, ok := util.AddUint64(fees...)
+	if !ok {
+		return 0, errors.New("sum of earned fees overflows uint64")
+	}
+	return sum, nil
+}
+
+// ExecutedTransactionsHash returns the executed transactions hash of the block's transactions.
+func (b *Block) ExecutedTransactionsHash(algorithm crypto.Hash) ([]byte, error) {
+	if b == nil {
+		return nil, errBlockIsNil
+	}
+	return ExecutedTransactionsHash(algorithm, b.Transactions)
+}
+
+// SumOfEarnedFees returns the sum of the actual fees of the block's transactions.
+func (b *Block) SumOfEarnedFees() (uint64, error) {
+	if b == nil {
+		return 0, errBlockIsNil
+	}
+	return SumOfEarnedFees(b.Transactions)
+}
+
 func (b *Block) HeaderHash(algorithm crypto.Hash) ([]byte, error) {
 	return b.Header.Hash(algorithm)
 }
@@ -158,10 +237,14 @@ func (b *Block) InputRecord() (*InputRecord, error) {
 	return uc.InputRecord, nil
 }
 
-func (b *Block) IsValid(algorithm crypto.Hash, shardConfHash []byte) error {
+func (b *Block) IsValid(algorithm crypto.Hash, shardConfHash []byte, opts ...BlockValidationOption) error {
 	if b == nil {
 		return errBlockIsNil
 	}
+	o := &blockValidationOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	if err := b.Header.IsValid(); err != nil {
 		return fmt.Errorf("block error: %w", err)
 	}
@@ -183,6 +266,29 @@ func (b *Block) IsValid(algorithm crypto.Hash, shardConfHash []byte) error {
 	if !bytes.Equal(hash, uc.InputRecord.BlockHash) {
 		return fmt.Errorf("block hash does not match to the block hash in the unicity certificate input record")
 	}
+	if o.checkExecutedTxs {
+		if err := b.checkExecutedTxs(algorithm, uc.InputRecord); err != nil {
+			return fmt.Errorf("executed transactions check failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *Block) checkExecutedTxs(algorithm crypto.Hash, ir *InputRecord) error {
+	etHash, err := b.ExecutedTransactionsHash(algorithm)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(etHash, ir.ETHash) {
+		return fmt.Errorf("executed transactions hash %X does not match the hash in the input record %X", etHash, ir.ETHash)
+	}
+	fees, err := b.SumOfEarnedFees()
+	if err != nil {
+		return err
+	}
+	if fees != ir.SumOfEarnedFees {
+		return fmt.Errorf("sum of earned fees %d does not match the sum in the input record %d", fees, ir.SumOfEarnedFees)
+	}
 	return nil
 }
 
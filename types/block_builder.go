@@ -0,0 +1,133 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+/*
+BlockBuilder assembles the block of the next shard round and the input record
+to be certified for it.
+
+PartitionID, ProposerID and StateHash must be set, other fields are optional.
+*/
+type BlockBuilder struct {
+	PartitionID  PartitionID
+	ShardID      ShardID
+	ProposerID   string
+	Transactions []*TransactionRecord
+	// state hash and summary value of the shard after executing the transactions
+	StateHash    hex.Bytes
+	SummaryValue hex.Bytes
+	// PreviousBlockHash defaults to the block hash certified by the previous UC.
+	// When the previous block was empty (the previous UC doesn't certify block
+	// hash) the hash of the latest non-empty block must be set explicitly.
+	PreviousBlockHash hex.Bytes
+	// TechnicalRecord certified by the previous UC, when set the round and epoch
+	// of the block are taken from it, otherwise the round follows the round
+	// of the previous UC and the epoch stays the same.
+	TechnicalRecord *TechnicalRecord
+	// Timestamp defaults to the timestamp of the unicity seal of the previous UC.
+	Timestamp uint64
+}
+
+/*
+Build returns the block following the round certified by "prevUC" and the input
+record to be certified for the block. The returned block doesn't have UC, it
+must be added once the input record has been certified.
+
+The state hash may only stay the same when the block is empty and the state
+hash must stay the same when the block is empty.
+*/
+func (bb *BlockBuilder) Build(algorithm crypto.Hash, prevUC *UnicityCertificate) (*Block, *InputRecord, error) {
+	if prevUC == nil {
+		return nil, nil, ErrUnicityCertificateIsNil
+	}
+	prevIR := prevUC.InputRecord
+	if prevIR == nil {
+		return nil, nil, ErrInputRecordIsNil
+	}
+	if prevUC.UnicitySeal == nil {
+		return nil, nil, ErrUnicitySealIsNil
+	}
+	if len(bb.StateHash) == 0 {
+		return nil, nil, errors.New("state hash is unassigned")
+	}
+	stateChanged := !bytes.Equal(prevIR.Hash, bb.StateHash)
+	if len(bb.Transactions) == 0 && stateChanged {
+		return nil, nil, errors.New("state hash changed but block has no transactions")
+	}
+	if len(bb.Transactions) > 0 && !stateChanged {
+		return nil, nil, errors.New("state hash didn't change but block has transactions")
+	}
+
+	round, epoch := prevIR.RoundNumber+1, prevIR.Epoch
+	if bb.TechnicalRecord != nil {
+		if err := bb.TechnicalRecord.Verify(prevUC, algorithm); err != nil {
+			return nil, nil, fmt.Errorf("verifying technical record: %w", err)
+		}
+		if !bb.TechnicalRecord.IsLeader(bb.ProposerID) {
+			return nil, nil, fmt.Errorf("expected leader %q, got %q", bb.TechnicalRecord.Leader, bb.ProposerID)
+		}
+		round, epoch = bb.TechnicalRecord.Round, bb.TechnicalRecord.Epoch
+	}
+
+	prevBlockHash := bb.PreviousBlockHash
+	if len(prevBlockHash) == 0 {
+		if len(prevIR.BlockHash) == 0 {
+			return nil, nil, errors.New("previous block hash is unassigned and previous UC doesn't certify block hash")
+		}
+		prevBlockHash = prevIR.BlockHash
+	}
+	block := &Block{
+		Header: &Header{
+			Version:           1,
+			PartitionID:       bb.PartitionID,
+			ShardID:           bb.ShardID,
+			ProposerID:        bb.ProposerID,
+			PreviousBlockHash: prevBlockHash,
+		},
+		Transactions: bb.Transactions,
+	}
+	if block.Transactions == nil {
+		block.Transactions = []*TransactionRecord{}
+	}
+
+	blockHash, err := BlockHash(algorithm, block.Header, block.Transactions, bb.StateHash, prevIR.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calculating block hash: %w", err)
+	}
+	etHash, err := ExecutedTransactionsHash(algorithm, block.Transactions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calculating executed transactions hash: %w", err)
+	}
+	fees, err := SumOfEarnedFees(block.Transactions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calculating sum of earned fees: %w", err)
+	}
+	timestamp := bb.Timestamp
+	if timestamp == 0 {
+		timestamp = prevUC.UnicitySeal.Timestamp
+	}
+
+	ir := &InputRecord{
+		Version:         1,
+		RoundNumber:     round,
+		Epoch:           epoch,
+		PreviousHash:    prevIR.Hash,
+		Hash:            bb.StateHash,
+		BlockHash:       blockHash,
+		SummaryValue:    bb.SummaryValue,
+		Timestamp:       timestamp,
+		SumOfEarnedFees: fees,
+		ETHash:          etHash,
+	}
+	if err := ir.IsValid(); err != nil {
+		return nil, nil, fmt.Errorf("invalid input record: %w", err)
+	}
+	return block, ir, nil
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestBlockBuilder_Build(t *testing.T) {
+	signer, _ := testsig.CreateSignerAndVerifier(t)
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: partitionID,
+		TypeIDLen:   8,
+		UnitIDLen:   256,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	pdrHash, err := pdr.Hash(crypto.SHA256)
+	require.NoError(t, err)
+	prevIR := &InputRecord{
+		Version:      1,
+		RoundNumber:  5,
+		Epoch:        1,
+		PreviousHash: []byte{1},
+		Hash:         []byte{2},
+		BlockHash:    []byte{3},
+		SummaryValue: []byte{4},
+		Timestamp:    NewTimestamp(),
+	}
+	prevUC := createUnicityCertificate(t, "test", signer, prevIR, make([]byte, 32), pdr)
+
+	t.Run("block with transactions", func(t *testing.T) {
+		bb := &BlockBuilder{
+			PartitionID:  partitionID,
+			ProposerID:   "test",
+			Transactions: []*TransactionRecord{createTransactionRecord(t, createTransactionOrder(t), 1), createTransactionRecord(t, createTransactionOrder(t), 2)},
+			StateHash:    []byte{5},
+			SummaryValue: []byte{6},
+		}
+		block, ir, err := bb.Build(crypto.SHA256, prevUC)
+		require.NoError(t, err)
+		require.EqualValues(t, 6, ir.RoundNumber)
+		require.EqualValues(t, 1, ir.Epoch)
+		require.Equal(t, prevIR.Hash, ir.PreviousHash)
+		require.EqualValues(t, []byte{5}, ir.Hash)
+		require.EqualValues(t, []byte{6}, ir.SummaryValue)
+		require.EqualValues(t, 3, ir.SumOfEarnedFees)
+		require.Equal(t, prevUC.UnicitySeal.Timestamp, ir.Timestamp)
+		require.NotEmpty(t, ir.BlockHash)
+		require.NotEmpty(t, ir.ETHash)
+		require.Equal(t, prevIR.BlockHash, block.Header.PreviousBlockHash)
+		require.Nil(t, block.UnicityCertificate)
+
+		// certify the IR and check that the block is valid
+		block.UnicityCertificate, err = createUnicityCertificate(t, "test", signer, ir, make([]byte, 32), pdr).MarshalCBOR()
+		require.NoError(t, err)
+		require.NoError(t, block.IsValid(crypto.SHA256, pdrHash, WithExecutedTxsCheck()))
+	})
+
+	t.Run("empty block", func(t *testing.T) {
+		bb := &BlockBuilder{
+			PartitionID:       partitionID,
+			ProposerID:        "test",
+			StateHash:         prevIR.Hash,
+			SummaryValue:      prevIR.SummaryValue,
+			PreviousBlockHash: []byte{9},
+			Timestamp:         prevIR.Timestamp + 1,
+		}
+		block, ir, err := bb.Build(crypto.SHA256, prevUC)
+		require.NoError(t, err)
+		require.Nil(t, ir.BlockHash)
+		require.Nil(t, ir.ETHash)
+		require.Zero(t, ir.SumOfEarnedFees)
+		require.Equal(t, prevIR.Timestamp+1, ir.Timestamp)
+		require.Empty(t, block.Transactions)
+		require.EqualValues(t, []byte{9}, block.Header.PreviousBlockHash)
+
+		block.UnicityCertificate, err = createUnicityCertificate(t, "test", signer, ir, make([]byte, 32), pdr).MarshalCBOR()
+		require.NoError(t, err)
+		require.NoError(t, block.IsValid(crypto.SHA256, pdrHash, WithExecutedTxsCheck()))
+	})
+
+	t.Run("state hash rules", func(t *testing.T) {
+		bb := &BlockBuilder{PartitionID: partitionID, ProposerID: "test", StateHash: []byte{5}, SummaryValue: []byte{6}}
+		_, _, err := bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, "state hash changed but block has no transactions")
+
+		bb.StateHash = prevIR.Hash
+		bb.Transactions = []*TransactionRecord{createTransactionRecord(t, createTransactionOrder(t), 1)}
+		_, _, err = bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, "state hash didn't change but block has transactions")
+
+		bb.StateHash = nil
+		_, _, err = bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, "state hash is unassigned")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		bb := &BlockBuilder{
+			PartitionID:  partitionID,
+			ProposerID:   "test",
+			Transactions: []*TransactionRecord{createTransactionRecord(t, createTransactionOrder(t), 1)},
+			StateHash:    []byte{5},
+		}
+		_, _, err := bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, "invalid input record: summary value is nil")
+
+		bb.SummaryValue = []byte{6}
+		bb.ProposerID = ""
+		_, _, err = bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, "calculating block hash: invalid block: block proposer node identifier is missing")
+
+		// previous round produced empty block, the hash of the previous block must be set
+		ir := *prevIR
+		ir.BlockHash = nil
+		_, _, err = bb.Build(crypto.SHA256, &UnicityCertificate{InputRecord: &ir, UnicitySeal: prevUC.UnicitySeal})
+		require.EqualError(t, err, "previous block hash is unassigned and previous UC doesn't certify block hash")
+
+		_, _, err = bb.Build(crypto.SHA256, nil)
+		require.ErrorIs(t, err, ErrUnicityCertificateIsNil)
+		_, _, err = bb.Build(crypto.SHA256, &UnicityCertificate{})
+		require.ErrorIs(t, err, ErrInputRecordIsNil)
+		_, _, err = bb.Build(crypto.SHA256, &UnicityCertificate{InputRecord: prevIR})
+		require.ErrorIs(t, err, ErrUnicitySealIsNil)
+	})
+
+	t.Run("technical record", func(t *testing.T) {
+		tr := &TechnicalRecord{Version: 1, Round: 6, Epoch: 2, Leader: "leader"}
+		trHash, err := tr.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		prevUC := createUnicityCertificate(t, "test", signer, prevIR, trHash, pdr)
+
+		bb := &BlockBuilder{
+			PartitionID:     partitionID,
+			ProposerID:      "leader",
+			StateHash:       prevIR.Hash,
+			SummaryValue:    prevIR.SummaryValue,
+			TechnicalRecord: tr,
+		}
+		_, ir, err := bb.Build(crypto.SHA256, prevUC)
+		require.NoError(t, err)
+		require.EqualValues(t, 6, ir.RoundNumber)
+		require.EqualValues(t, 2, ir.Epoch)
+		require.NoError(t, tr.CheckInputRecord(ir))
+
+		bb.ProposerID = "test"
+		_, _, err = bb.Build(crypto.SHA256, prevUC)
+		require.EqualError(t, err, `expected leader "leader", got "test"`)
+
+		// technical record not certified by the previous UC
+		bb.ProposerID = "leader"
+		bb.TechnicalRecord = &TechnicalRecord{Version: 1, Round: 6, Epoch: 3, Leader: "leader"}
+		_, _, err = bb.Build(crypto.SHA256, prevUC)
+		require.ErrorContains(t, err, "verifying technical record: technical record hash")
+	})
+}
@@ -0,0 +1,264 @@
+package types
+
+import (
+	"bytes"
+	"cmp"
+	"crypto"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrBlockChainInvalidBlock = errors.New("invalid block")
+	ErrBlockChainInvalidUC    = errors.New("invalid unicity certificate")
+	ErrBlockChainDuplicate    = errors.New("duplicate block")
+	ErrBlockChainEquivocation = errors.New("equivocating unicity certificates")
+	ErrBlockChainGap          = errors.New("gap in the block chain")
+	ErrBlockChainStateLink    = errors.New("broken state hash linkage")
+	ErrBlockChainBlockLink    = errors.New("broken previous block hash linkage")
+)
+
+type (
+	/*
+		BlockChainError describes the first inconsistency found in the blocks of
+		a shard. Use errors.Is with one of the ErrBlockChain* errors to find out
+		the kind of the inconsistency.
+	*/
+	BlockChainError struct {
+		Index int    // index of the offending block in the sequence of blocks given to the validator
+		Round uint64 // round of the offending block, zero when the round is not known
+		Err   error
+	}
+
+	/*
+		ShardChainReport is the result of validating the blocks of a shard.
+	*/
+	ShardChainReport struct {
+		PartitionID PartitionID
+		ShardID     ShardID
+		FirstRound  uint64 // round of the first consistent block
+		LastRound   uint64 // round of the last consistent block
+		Blocks      int    // number of consistent blocks, including blocks with repeat UC
+		Skipped     int    // number of blocks not checked because of the earlier inconsistency
+		Err         *BlockChainError
+	}
+
+	/*
+		BlockChainValidator checks that a sequence of blocks forms a consistent
+		history of the shards the blocks belong to. Blocks of multiple shards may
+		be interleaved in the sequence but the blocks of a shard must be in the
+		order of rounds.
+	*/
+	BlockChainValidator struct {
+		ucValidator   *UCValidator
+		hashAlgorithm crypto.Hash
+		allowGaps     bool
+		count         int
+		shards        map[PartitionShardID]*shardChainState
+	}
+
+	BlockChainOption func(v *BlockChainValidator)
+
+	shardChainState struct {
+		report        *ShardChainReport
+		lastUC        *UnicityCertificate
+		lastBlockHash []byte // hash of the latest non-empty block
+	}
+)
+
+func (e *BlockChainError) Error() string {
+	if e.Round == 0 {
+		return fmt.Sprintf("block chain broken at index %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("block chain broken at index %d (round %d): %v", e.Index, e.Round, e.Err)
+}
+
+func (e *BlockChainError) Unwrap() error { return e.Err }
+
+/*
+NewBlockChainValidator returns block chain validator which uses "trustBase" to
+acquire the root trust base of the UC's epoch and "shardConf" to acquire the
+shard configuration of the UC's shard epoch, see UCValidator.
+*/
+func NewBlockChainValidator(trustBase TrustBaseLookup, shardConf ShardConfLookup, algorithm crypto.Hash, opts ...BlockChainOption) (*BlockChainValidator, error) {
+	ucv, err := NewUCValidator(trustBase, shardConf, algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("creating UC validator: %w", err)
+	}
+	v := &BlockChainValidator{
+		ucValidator:   ucv,
+		hashAlgorithm: algorithm,
+		shards:        make(map[PartitionShardID]*shardChainState),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+/*
+WithEmptyBlockGaps allows gaps in the rounds of the blocks as long as the state
+hash linkage holds, ie it is assumed that the missing blocks were empty and
+were not stored.
+*/
+func WithEmptyBlockGaps() BlockChainOption {
+	return func(v *BlockChainValidator) {
+		v.allowGaps = true
+	}
+}
+
+/*
+Add checks the next block of the sequence:
+  - block must be valid, including the executed transactions hash and the sum
+    of the earned fees certified by the UC;
+  - UC must be valid according to the trust base and shard configuration of
+    the UC's epochs;
+  - round of the block must follow the round of the previous block of the shard
+    and the previous state hash must be the state hash of the previous block;
+  - Header.PreviousBlockHash must be the hash of the latest non-empty block;
+  - the same block with repeat UC (certified again in a later root round) is
+    accepted, the same block with the same UC is reported as duplicate.
+
+The first inconsistency of the shard is returned as *BlockChainError and is
+recorded in the report of the shard. Once inconsistency is found the following
+blocks of the shard are not checked and Add returns nil for them.
+
+Error is also returned when the shard of the block can't be determined, such
+error is not recorded in any of the reports.
+*/
+func (v *BlockChainValidator) Add(block *Block) error {
+	idx := v.count
+	v.count++
+	if block == nil {
+		return &BlockChainError{Index: idx, Err: fmt.Errorf("%w: %w", ErrBlockChainInvalidBlock, errBlockIsNil)}
+	}
+	if block.Header == nil {
+		return &BlockChainError{Index: idx, Err: fmt.Errorf("%w: %w", ErrBlockChainInvalidBlock, errBlockHeaderIsNil)}
+	}
+
+	key := PartitionShardID{PartitionID: block.Header.PartitionID, ShardID: block.Header.ShardID.Key()}
+	shard, ok := v.shards[key]
+	if !ok {
+		shard = &shardChainState{
+			report: &ShardChainReport{PartitionID: block.Header.PartitionID, ShardID: block.Header.ShardID},
+		}
+		v.shards[key] = shard
+	}
+	if shard.report.Err != nil {
+		shard.report.Skipped++
+		return nil
+	}
+
+	uc, err := v.checkNext(shard, block)
+	if err != nil {
+		shard.report.Err = &BlockChainError{Index: idx, Round: uc.GetRoundNumber(), Err: err}
+		return shard.report.Err
+	}
+	if shard.report.Blocks == 0 {
+		shard.report.FirstRound = uc.GetRoundNumber()
+	}
+	shard.report.LastRound = uc.GetRoundNumber()
+	shard.report.Blocks++
+	return nil
+}
+
+/*
+Validate checks the blocks in order, see Add, and returns the reports of all
+the shards seen so far. Error is returned only when the shard of a block can't
+be determined.
+*/
+func (v *BlockChainValidator) Validate(blocks []*Block) ([]*ShardChainReport, error) {
+	for _, b := range blocks {
+		if err := v.Add(b); err != nil && (b == nil || b.Header == nil) {
+			return nil, err
+		}
+	}
+	return v.Report(), nil
+}
+
+/*
+Report returns the reports of all the shards seen so far, ordered by partition
+and shard ID.
+*/
+func (v *BlockChainValidator) Report() []*ShardChainReport {
+	reports := make([]*ShardChainReport, 0, len(v.shards))
+	for _, s := range v.shards {
+		r := *s.report
+		reports = append(reports, &r)
+	}
+	slices.SortFunc(reports, func(a, b *ShardChainReport) int {
+		if c := cmp.Compare(a.PartitionID, b.PartitionID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ShardID.Key(), b.ShardID.Key())
+	})
+	return reports
+}
+
+/*
+checkNext validates the block against the state of the shard and updates the
+state when the block is consistent. The UC of the block is returned even when
+the block is not consistent (as long as the UC could be decoded).
+*/
+func (v *BlockChainValidator) checkNext(shard *shardChainState, block *Block) (*UnicityCertificate, error) {
+	uc, err := block.getUCv1()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBlockChainInvalidBlock, err)
+	}
+	if err := block.IsValid(v.hashAlgorithm, nil, WithExecutedTxsCheck()); err != nil {
+		return uc, fmt.Errorf("%w: %w", ErrBlockChainInvalidBlock, err)
+	}
+	if !uc.GetShardID().Equal(block.Header.ShardID) {
+		return uc, fmt.Errorf("%w: block is of shard %q but UC is of shard %q", ErrBlockChainInvalidBlock, block.Header.ShardID, uc.GetShardID())
+	}
+	if err := v.ucValidator.Validate(uc, nil); err != nil {
+		return uc, fmt.Errorf("%w: %w", ErrBlockChainInvalidUC, err)
+	}
+
+	prev := shard.lastUC
+	if prev == nil {
+		shard.lastUC = uc
+		shard.lastBlockHash = block.Header.PreviousBlockHash
+		if len(uc.InputRecord.BlockHash) != 0 {
+			shard.lastBlockHash = uc.InputRecord.BlockHash
+		}
+		return uc, nil
+	}
+
+	sameIR, err := EqualIR(prev.InputRecord, uc.InputRecord)
+	if err != nil {
+		return uc, fmt.Errorf("%w: comparing input records: %w", ErrBlockChainInvalidUC, err)
+	}
+	if sameIR {
+		if uc.GetRootRoundNumber() <= prev.GetRootRoundNumber() {
+			return uc, fmt.Errorf("%w: round %d is already certified in root round %d", ErrBlockChainDuplicate, uc.GetRoundNumber(), prev.GetRootRoundNumber())
+		}
+		// the same block with repeat UC, the block has already been accounted for
+		shard.lastUC = uc
+		return uc, nil
+	}
+	if uc.IsDuplicate(prev) {
+		return uc, fmt.Errorf("%w: different input records certified in the same root round %d", ErrBlockChainEquivocation, uc.GetRootRoundNumber())
+	}
+	if _, err := checkEquivocation(prev, uc); err != nil {
+		return uc, fmt.Errorf("%w: %w", ErrBlockChainEquivocation, err)
+	}
+	if next := prev.GetRoundNumber() + 1; uc.GetRoundNumber() != next && !v.allowGaps {
+		return uc, fmt.Errorf("%w: expected round %d, got %d", ErrBlockChainGap, next, uc.GetRoundNumber())
+	}
+	if !uc.IsSuccessor(prev) {
+		return uc, fmt.Errorf("%w: previous state hash %X does not match the state hash %X of round %d",
+			ErrBlockChainStateLink, uc.GetPreviousStateHash(), prev.GetStateHash(), prev.GetRoundNumber())
+	}
+	if !bytes.Equal(block.Header.PreviousBlockHash, shard.lastBlockHash) {
+		return uc, fmt.Errorf("%w: previous block hash %X does not match the hash %X of the latest non-empty block",
+			ErrBlockChainBlockLink, []byte(block.Header.PreviousBlockHash), shard.lastBlockHash)
+	}
+
+	shard.lastUC = uc
+	if len(uc.InputRecord.BlockHash) != 0 {
+		shard.lastBlockHash = uc.InputRecord.BlockHash
+	}
+	return uc, nil
+}
@@ -0,0 +1,207 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestBlockChainValidator(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: partitionID,
+		TypeIDLen:   8,
+		UnitIDLen:   256,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	newValidator := func(t *testing.T, opts ...BlockChainOption) *BlockChainValidator {
+		v, err := NewBlockChainValidator(
+			func(epoch uint64) (RootTrustBase, error) { return tb, nil },
+			func(partition PartitionID, shard ShardID, epoch uint64) (*PartitionDescriptionRecord, error) {
+				return pdr, nil
+			},
+			crypto.SHA256,
+			opts...,
+		)
+		require.NoError(t, err)
+		return v
+	}
+
+	// certify "ir" in the given root round
+	certify := func(t *testing.T, s abcrypto.Signer, ir *InputRecord, rootRound uint64) *UnicityCertificate {
+		uc := createUnicityCertificate(t, "test", s, ir, make([]byte, 32), pdr)
+		uc.UnicitySeal.RootChainRoundNumber = rootRound
+		uc.UnicitySeal.Signatures = nil
+		require.NoError(t, uc.UnicitySeal.Sign("test", s))
+		return uc
+	}
+	addUC := func(t *testing.T, b *Block, uc *UnicityCertificate) *Block {
+		var err error
+		b.UnicityCertificate, err = uc.MarshalCBOR()
+		require.NoError(t, err)
+		return b
+	}
+
+	genesisUC := certify(t, signer, &InputRecord{
+		Version:      1,
+		RoundNumber:  5,
+		PreviousHash: []byte{1},
+		Hash:         []byte{2},
+		BlockHash:    []byte{3},
+		SummaryValue: []byte{4},
+		Timestamp:    NewTimestamp(),
+	}, 10)
+
+	// builds block for the round following the "prevUC" round
+	nextBlock := func(t *testing.T, prevUC *UnicityCertificate, prevBlockHash []byte, txCount int) (*Block, *InputRecord) {
+		bb := &BlockBuilder{
+			PartitionID:       partitionID,
+			ProposerID:        "test",
+			StateHash:         prevUC.GetStateHash(),
+			SummaryValue:      prevUC.GetSummaryValue(),
+			PreviousBlockHash: prevBlockHash,
+		}
+		for i := range txCount {
+			bb.Transactions = append(bb.Transactions, createTransactionRecord(t, createTransactionOrder(t), uint64(i+1)))
+			bb.StateHash = append([]byte{byte(prevUC.GetRoundNumber())}, bb.StateHash...)
+		}
+		b, ir, err := bb.Build(crypto.SHA256, prevUC)
+		require.NoError(t, err)
+		return b, ir
+	}
+
+	// valid chain of rounds 6..9 where round 7 is empty
+	var blocks []*Block
+	var ucs []*UnicityCertificate
+	prevUC := genesisUC
+	prevBlockHash := genesisUC.GetBlockHash()
+	for i, txCount := range []int{2, 0, 1, 3} {
+		b, ir := nextBlock(t, prevUC, prevBlockHash, txCount)
+		prevUC = certify(t, signer, ir, uint64(11+i))
+		blocks = append(blocks, addUC(t, b, prevUC))
+		ucs = append(ucs, prevUC)
+		if ir.BlockHash != nil {
+			prevBlockHash = ir.BlockHash
+		}
+	}
+
+	t.Run("valid chain", func(t *testing.T) {
+		reports, err := newValidator(t).Validate(blocks)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		r := reports[0]
+		require.Nil(t, r.Err)
+		require.Equal(t, partitionID, r.PartitionID)
+		require.EqualValues(t, 6, r.FirstRound)
+		require.EqualValues(t, 9, r.LastRound)
+		require.Equal(t, 4, r.Blocks)
+		require.Zero(t, r.Skipped)
+	})
+
+	t.Run("repeat UC", func(t *testing.T) {
+		repeat := *blocks[1]
+		addUC(t, &repeat, certify(t, signer, ucs[1].InputRecord, 100))
+		v := newValidator(t)
+		for _, b := range []*Block{blocks[0], blocks[1], &repeat} {
+			require.NoError(t, v.Add(b))
+		}
+		// the UC of the next block is of older root round than the repeat UC
+		err := v.Add(blocks[2])
+		require.ErrorIs(t, err, ErrBlockChainEquivocation)
+		require.ErrorContains(t, err, "older root round")
+
+		v = newValidator(t)
+		reports, err := v.Validate([]*Block{blocks[0], blocks[1], &repeat})
+		require.NoError(t, err)
+		require.Nil(t, reports[0].Err)
+		require.Equal(t, 3, reports[0].Blocks)
+		require.EqualValues(t, 7, reports[0].LastRound)
+	})
+
+	t.Run("duplicate block", func(t *testing.T) {
+		v := newValidator(t)
+		require.NoError(t, v.Add(blocks[0]))
+		err := v.Add(blocks[0])
+		require.ErrorIs(t, err, ErrBlockChainDuplicate)
+		require.EqualError(t, err, "block chain broken at index 1 (round 6): duplicate block: round 6 is already certified in root round 11")
+	})
+
+	t.Run("missing empty block", func(t *testing.T) {
+		chain := []*Block{blocks[0], blocks[2], blocks[3]}
+		reports, err := newValidator(t).Validate(chain)
+		require.NoError(t, err)
+		r := reports[0]
+		require.ErrorIs(t, r.Err, ErrBlockChainGap)
+		require.EqualError(t, r.Err, "block chain broken at index 1 (round 8): gap in the block chain: expected round 7, got 8")
+		require.Equal(t, 1, r.Blocks)
+		require.Equal(t, 1, r.Skipped)
+
+		reports, err = newValidator(t, WithEmptyBlockGaps()).Validate(chain)
+		require.NoError(t, err)
+		require.Nil(t, reports[0].Err)
+		require.Equal(t, 3, reports[0].Blocks)
+	})
+
+	t.Run("missing non-empty block", func(t *testing.T) {
+		reports, err := newValidator(t, WithEmptyBlockGaps()).Validate([]*Block{blocks[0], blocks[1], blocks[3]})
+		require.NoError(t, err)
+		require.ErrorIs(t, reports[0].Err, ErrBlockChainStateLink)
+		require.EqualValues(t, 9, reports[0].Err.Round)
+		require.Equal(t, 2, reports[0].Err.Index)
+	})
+
+	t.Run("broken previous block hash", func(t *testing.T) {
+		// block following the empty block must refer to the latest non-empty block,
+		// not to the block before it
+		b, ir := nextBlock(t, ucs[1], genesisUC.GetBlockHash(), 1)
+		addUC(t, b, certify(t, signer, ir, 13))
+		v := newValidator(t)
+		require.NoError(t, v.Add(blocks[0]))
+		require.NoError(t, v.Add(blocks[1]))
+		err := v.Add(b)
+		require.ErrorIs(t, err, ErrBlockChainBlockLink)
+	})
+
+	t.Run("invalid UC", func(t *testing.T) {
+		otherSigner, _ := testsig.CreateSignerAndVerifier(t)
+		b, ir := nextBlock(t, genesisUC, genesisUC.GetBlockHash(), 1)
+		addUC(t, b, certify(t, otherSigner, ir, 11))
+		err := newValidator(t).Add(b)
+		require.ErrorIs(t, err, ErrBlockChainInvalidUC)
+		require.ErrorContains(t, err, "quorum not reached")
+	})
+
+	t.Run("fee sum mismatch", func(t *testing.T) {
+		b, ir := nextBlock(t, genesisUC, genesisUC.GetBlockHash(), 2)
+		ir.SumOfEarnedFees++
+		addUC(t, b, certify(t, signer, ir, 11))
+		err := newValidator(t).Add(b)
+		require.ErrorIs(t, err, ErrBlockChainInvalidBlock)
+		require.ErrorContains(t, err, "sum of earned fees 3 does not match the sum in the input record 4")
+	})
+
+	t.Run("unattributable block", func(t *testing.T) {
+		v := newValidator(t)
+		_, err := v.Validate([]*Block{blocks[0], nil})
+		require.ErrorIs(t, err, ErrBlockChainInvalidBlock)
+		require.EqualError(t, err, "block chain broken at index 1: invalid block: block is nil")
+
+		require.ErrorIs(t, v.Add(&Block{}), ErrBlockChainInvalidBlock)
+		// errors of unattributable blocks are not recorded
+		require.Len(t, v.Report(), 1)
+		require.Nil(t, v.Report()[0].Err)
+	})
+
+	t.Run("constructor", func(t *testing.T) {
+		_, err := NewBlockChainValidator(nil, func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) { return pdr, nil }, crypto.SHA256)
+		require.EqualError(t, err, "creating UC validator: trust base lookup is nil")
+	})
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"crypto"
+	"math"
 	"testing"
 	"time"
 
@@ -238,6 +239,119 @@ func TestBlock_IsValid(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualError(t, b.IsValid(crypto.SHA256, h), "block hash does not match to the block hash in the unicity certificate input record")
 	})
+	t.Run("executed transactions check", func(t *testing.T) {
+		signer, _ := testsig.CreateSignerAndVerifier(t)
+		sdrs := &PartitionDescriptionRecord{
+			Version:     1,
+			PartitionID: partitionID,
+			T2Timeout:   2500 * time.Millisecond,
+		}
+		txr1 := createTransactionRecord(t, createTransactionOrder(t), 1)
+		txr2 := createTransactionRecord(t, createTransactionOrder(t), 2)
+		txs := []*TransactionRecord{txr1, txr2}
+		etHash, err := ExecutedTransactionsHash(crypto.SHA256, txs)
+		require.NoError(t, err)
+		h, err := sdrs.Hash(crypto.SHA256)
+		require.NoError(t, err)
+
+		newBlock := func(t *testing.T, etHash []byte, fees uint64) *Block {
+			inputRecord := &InputRecord{
+				Version:         1,
+				PreviousHash:    []byte{0, 0, 1},
+				Hash:            []byte{0, 0, 2},
+				SummaryValue:    []byte{0, 0, 4},
+				Timestamp:       NewTimestamp(),
+				RoundNumber:     1,
+				SumOfEarnedFees: fees,
+				ETHash:          etHash,
+			}
+			uc, err := (&UnicityCertificate{Version: 1, InputRecord: inputRecord}).MarshalCBOR()
+			require.NoError(t, err)
+			b := &Block{
+				Header: &Header{
+					Version:           1,
+					PartitionID:       partitionID,
+					ProposerID:        "test",
+					PreviousBlockHash: []byte{1, 2, 3},
+				},
+				Transactions:       txs,
+				UnicityCertificate: uc,
+			}
+			inputRecord, err = b.CalculateBlockHash(crypto.SHA256)
+			require.NoError(t, err)
+			b.UnicityCertificate, err = createUnicityCertificate(t, "test", signer, inputRecord, make([]byte, 32), sdrs).MarshalCBOR()
+			require.NoError(t, err)
+			return b
+		}
+
+		b := newBlock(t, etHash, 3)
+		require.NoError(t, b.IsValid(crypto.SHA256, h, WithExecutedTxsCheck()))
+
+		b = newBlock(t, etHash, 4)
+		require.NoError(t, b.IsValid(crypto.SHA256, h))
+		require.EqualError(t, b.IsValid(crypto.SHA256, h, WithExecutedTxsCheck()),
+			"executed transactions check failed: sum of earned fees 3 does not match the sum in the input record 4")
+
+		b = newBlock(t, []byte{1, 2, 3}, 3)
+		require.NoError(t, b.IsValid(crypto.SHA256, h))
+		require.ErrorContains(t, b.IsValid(crypto.SHA256, h, WithExecutedTxsCheck()),
+			"executed transactions check failed: executed transactions hash")
+	})
+}
+
+func TestExecutedTransactionsHash(t *testing.T) {
+	h, err := ExecutedTransactionsHash(crypto.SHA256, nil)
+	require.NoError(t, err)
+	require.Nil(t, h)
+
+	txr1 := createTransactionRecord(t, createTransactionOrder(t), 1)
+	txr2 := createTransactionRecord(t, createTransactionOrder(t), 2)
+	txr2.ServerMetadata.SuccessIndicator = TxStatusFailed
+	h1, err := ExecutedTransactionsHash(crypto.SHA256, []*TransactionRecord{txr1, txr2})
+	require.NoError(t, err)
+	require.Len(t, h1, 32)
+	// order of the transactions matters
+	h2, err := ExecutedTransactionsHash(crypto.SHA256, []*TransactionRecord{txr2, txr1})
+	require.NoError(t, err)
+	require.NotEqual(t, h1, h2)
+
+	b := &Block{Transactions: []*TransactionRecord{txr1, txr2}}
+	h3, err := b.ExecutedTransactionsHash(crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, h1, h3)
+
+	_, err = ExecutedTransactionsHash(crypto.SHA256, []*TransactionRecord{txr1, nil})
+	require.EqualError(t, err, "transaction record 1 is nil")
+
+	b = nil
+	_, err = b.ExecutedTransactionsHash(crypto.SHA256)
+	require.ErrorIs(t, err, errBlockIsNil)
+}
+
+func TestSumOfEarnedFees(t *testing.T) {
+	fees, err := SumOfEarnedFees(nil)
+	require.NoError(t, err)
+	require.Zero(t, fees)
+
+	txr1 := createTransactionRecord(t, createTransactionOrder(t), 1)
+	txr2 := createTransactionRecord(t, createTransactionOrder(t), 2)
+	fees, err = SumOfEarnedFees([]*TransactionRecord{txr1, txr2})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, fees)
+
+	b := &Block{Transactions: []*TransactionRecord{txr1, txr2}}
+	fees, err = b.SumOfEarnedFees()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, fees)
+
+	txr2.ServerMetadata.ActualFee = math.MaxUint64
+	_, err = SumOfEarnedFees([]*TransactionRecord{txr1, txr2})
+	require.EqualError(t, err, "sum of earned fees overflows uint64")
+
+	_, err = SumOfEarnedFees([]*TransactionRecord{txr1, nil})
+	require.EqualError(t, err, "transaction record 1 is nil")
+	_, err = SumOfEarnedFees([]*TransactionRecord{{Version: 1}})
+	require.EqualError(t, err, "transaction record 0: server metadata is nil")
 }
 
 func TestBlock_Hash(t *testing.T) {
@@ -0,0 +1,243 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alphabill-org/alphabill-go-base/crypto"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var ErrBlockCertificationRequestIsNil = errors.New("block certification request is nil")
+
+const (
+	// QuorumInProgress - no IR has quorum yet but it is still possible to achieve.
+	QuorumInProgress QuorumStatus = iota + 1
+	// QuorumAchieved - quorum of the validators agree on the IR.
+	QuorumAchieved
+	// QuorumNotPossible - validators disagree so that no IR can achieve quorum.
+	QuorumNotPossible
+)
+
+type (
+	/*
+		BlockCertificationRequest is sent by the shard validator to the root chain
+		to request certification of the shard's new state.
+	*/
+	BlockCertificationRequest struct {
+		_           struct{}     `cbor:",toarray"`
+		Version     ABVersion    `json:"version"`
+		PartitionID PartitionID  `json:"partitionId"`
+		ShardID     ShardID      `json:"shardId"`
+		NodeID      string       `json:"nodeId"`
+		InputRecord *InputRecord `json:"inputRecord"`
+		BlockSize   uint64       `json:"blockSize"` // see Block.Size
+		StateSize   uint64       `json:"stateSize"`
+		Signature   hex.Bytes    `json:"signature"`
+	}
+
+	// QuorumStatus is the outcome of checking the set of certification requests for quorum.
+	QuorumStatus uint8
+)
+
+func (x *BlockCertificationRequest) IsValid() error {
+	if x == nil {
+		return ErrBlockCertificationRequestIsNil
+	}
+	if x.Version != 1 {
+		return ErrInvalidVersion(x)
+	}
+	if x.PartitionID == 0 {
+		return fmt.Errorf("invalid partition identifier: %s", x.PartitionID)
+	}
+	if x.NodeID == "" {
+		return errors.New("node identifier is empty")
+	}
+	if err := x.InputRecord.IsValid(); err != nil {
+		return fmt.Errorf("invalid input record: %w", err)
+	}
+	return nil
+}
+
+// IRRound returns the shard round number of the input record.
+func (x *BlockCertificationRequest) IRRound() uint64 {
+	if x == nil || x.InputRecord == nil {
+		return 0
+	}
+	return x.InputRecord.RoundNumber
+}
+
+// SigBytes - serialize everything except signature (used for sign and verify)
+func (x BlockCertificationRequest) SigBytes() ([]byte, error) {
+	x.Signature = nil
+	return x.MarshalCBOR()
+}
+
+/*
+Sign signs the request, the NodeID field must be set to the ID of the signer.
+*/
+func (x *BlockCertificationRequest) Sign(signer crypto.Signer) error {
+	if signer == nil {
+		return ErrSignerIsNil
+	}
+	bs, err := x.SigBytes()
+	if err != nil {
+		return fmt.Errorf("failed to marshal block certification request: %w", err)
+	}
+	if x.Signature, err = signer.SignBytes(bs); err != nil {
+		return fmt.Errorf("sign failed, %w", err)
+	}
+	return nil
+}
+
+/*
+Verify checks that the request is valid, is for the shard described by the "pdr"
+and is signed by one of the validators listed in the "pdr".
+*/
+func (x *BlockCertificationRequest) Verify(pdr *PartitionDescriptionRecord) error {
+	if err := x.IsValid(); err != nil {
+		return fmt.Errorf("invalid block certification request: %w", err)
+	}
+	if pdr == nil {
+		return ErrSystemDescriptionIsNil
+	}
+	if x.PartitionID != pdr.PartitionID {
+		return fmt.Errorf("partition ID mismatch: expected %s, got %s", pdr.PartitionID, x.PartitionID)
+	}
+	if !x.ShardID.Equal(pdr.ShardID) {
+		return fmt.Errorf("shard ID mismatch: expected %s, got %s", pdr.ShardID, x.ShardID)
+	}
+	if x.InputRecord.Epoch != pdr.Epoch {
+		return fmt.Errorf("epoch mismatch: shard configuration is for epoch %d, input record has epoch %d", pdr.Epoch, x.InputRecord.Epoch)
+	}
+	node := pdr.getValidator(x.NodeID)
+	if node == nil {
+		return fmt.Errorf("node %q is not a validator of the shard", x.NodeID)
+	}
+	verifier, err := node.SigVerifier()
+	if err != nil {
+		return fmt.Errorf("validator %q: %w", x.NodeID, err)
+	}
+	bs, err := x.SigBytes()
+	if err != nil {
+		return fmt.Errorf("failed to marshal block certification request: %w", err)
+	}
+	if err := verifier.VerifyBytes(x.Signature, bs); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	return nil
+}
+
+func (x *BlockCertificationRequest) GetVersion() ABVersion {
+	if x != nil && x.Version > 0 {
+		return x.Version
+	}
+	return 1
+}
+
+func (x *BlockCertificationRequest) MarshalCBOR() ([]byte, error) {
+	type alias BlockCertificationRequest
+	if x.Version == 0 {
+		x.Version = x.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(BlockCertificationRequestTag, (*alias)(x))
+}
+
+func (x *BlockCertificationRequest) UnmarshalCBOR(data []byte) error {
+	type alias BlockCertificationRequest
+	if err := Cbor.UnmarshalTaggedValue(BlockCertificationRequestTag, data, (*alias)(x)); err != nil {
+		return fmt.Errorf("decoding block certification request: %w", err)
+	}
+	return EnsureVersion(x, x.Version, 1)
+}
+
+/*
+CheckCertificationQuorum verifies the requests against the "pdr" and checks
+whether the validators (weighted by stake) agree on the input record.
+Input records are compared using AssertEqualIR.
+
+When the quorum is achieved the agreed input record is returned. Error is
+returned when any of the requests is invalid, requests are not for the same
+round or there are multiple requests from the same validator.
+*/
+func CheckCertificationQuorum(pdr *PartitionDescriptionRecord, requests []*BlockCertificationRequest) (*InputRecord, QuorumStatus, error) {
+	if pdr == nil {
+		return nil, 0, ErrSystemDescriptionIsNil
+	}
+	totalStake, err := TotalStake(pdr.Validators)
+	if err != nil {
+		return nil, 0, fmt.Errorf("calculating total stake of the validators: %w", err)
+	}
+	quorum := MinQuorumThreshold(totalStake)
+
+	type irVotes struct {
+		ir    *InputRecord
+		stake uint64
+	}
+	var votes []*irVotes
+	var votedStake uint64
+	signers := make(map[string]struct{}, len(requests))
+	for i, req := range requests {
+		if err := req.Verify(pdr); err != nil {
+			return nil, 0, fmt.Errorf("request %d: %w", i, err)
+		}
+		if _, ok := signers[req.NodeID]; ok {
+			return nil, 0, fmt.Errorf("request %d: duplicate request from node %q", i, req.NodeID)
+		}
+		signers[req.NodeID] = struct{}{}
+		if req.IRRound() != requests[0].IRRound() {
+			return nil, 0, fmt.Errorf("request %d: round %d differs from the round %d of the first request", i, req.IRRound(), requests[0].IRRound())
+		}
+
+		// votes are from distinct validators so the sums can't exceed the total stake
+		stake := pdr.getValidator(req.NodeID).Stake
+		votedStake += stake
+		var v *irVotes
+		for _, iv := range votes {
+			if AssertEqualIR(iv.ir, req.InputRecord) == nil {
+				v = iv
+				break
+			}
+		}
+		if v == nil {
+			v = &irVotes{ir: req.InputRecord}
+			votes = append(votes, v)
+		}
+		v.stake += stake
+	}
+
+	var maxVotes uint64
+	for _, v := range votes {
+		if v.stake >= quorum {
+			return v.ir, QuorumAchieved, nil
+		}
+		maxVotes = max(maxVotes, v.stake)
+	}
+	// validators who haven't voted yet could join the IR with most votes
+	if maxVotes+(totalStake-votedStake) < quorum {
+		return nil, QuorumNotPossible, nil
+	}
+	return nil, QuorumInProgress, nil
+}
+
+func (pdr *PartitionDescriptionRecord) getValidator(nodeID string) *NodeInfo {
+	for _, v := range pdr.Validators {
+		if v.NodeID == nodeID {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s QuorumStatus) String() string {
+	switch s {
+	case QuorumInProgress:
+		return "in progress"
+	case QuorumAchieved:
+		return "achieved"
+	case QuorumNotPossible:
+		return "not possible"
+	default:
+		return fmt.Sprintf("QuorumStatus(%d)", uint8(s))
+	}
+}
@@ -0,0 +1,201 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCertificationRequest(t *testing.T) {
+	keys := genKeys(4)
+	pdr := &PartitionDescriptionRecord{
+		Version:         1,
+		NetworkID:       NetworkMainNet,
+		PartitionID:     0x01020304,
+		PartitionTypeID: 1,
+		TypeIDLen:       8,
+		UnitIDLen:       256,
+		T2Timeout:       2500 * time.Millisecond,
+		Epoch:           2,
+	}
+	for _, id := range []string{"1", "2", "3", "4"} {
+		pdr.Validators = append(pdr.Validators, &NodeInfo{NodeID: id, SigKey: keys[id].publicKey, Stake: 1})
+	}
+	newIR := func(hash byte) *InputRecord {
+		return &InputRecord{
+			Version:      1,
+			RoundNumber:  5,
+			Epoch:        2,
+			PreviousHash: []byte{1},
+			Hash:         []byte{hash},
+			BlockHash:    []byte{3},
+			SummaryValue: []byte{4},
+			Timestamp:    NewTimestamp(),
+		}
+	}
+	newRequest := func(t *testing.T, nodeID string, ir *InputRecord) *BlockCertificationRequest {
+		req := &BlockCertificationRequest{
+			Version:     1,
+			PartitionID: pdr.PartitionID,
+			NodeID:      nodeID,
+			InputRecord: ir,
+			BlockSize:   10,
+			StateSize:   20,
+		}
+		require.NoError(t, req.Sign(keys[nodeID].signer))
+		return req
+	}
+
+	t.Run("sign and verify", func(t *testing.T) {
+		req := newRequest(t, "1", newIR(2))
+		require.NoError(t, req.Verify(pdr))
+		require.EqualValues(t, 5, req.IRRound())
+
+		require.ErrorIs(t, req.Sign(nil), ErrSignerIsNil)
+		require.ErrorIs(t, req.Verify(nil), ErrSystemDescriptionIsNil)
+
+		// signed by the key of other validator
+		req.NodeID = "2"
+		require.ErrorContains(t, req.Verify(pdr), "signature verification failed")
+
+		// modified after signing
+		req = newRequest(t, "1", newIR(2))
+		req.StateSize++
+		require.ErrorContains(t, req.Verify(pdr), "signature verification failed")
+
+		// not a validator of the shard
+		req = newRequest(t, "1", newIR(2))
+		req.NodeID = "5"
+		require.EqualError(t, req.Verify(pdr), `node "5" is not a validator of the shard`)
+	})
+
+	t.Run("shard mismatch", func(t *testing.T) {
+		req := newRequest(t, "1", newIR(2))
+		req.PartitionID = 1
+		require.EqualError(t, req.Verify(pdr), "partition ID mismatch: expected 01020304, got 00000001")
+
+		req = newRequest(t, "1", newIR(2))
+		req.ShardID, _ = ShardID{}.Split()
+		require.ErrorContains(t, req.Verify(pdr), "shard ID mismatch")
+
+		ir := newIR(2)
+		ir.Epoch = 1
+		req = newRequest(t, "1", ir)
+		require.EqualError(t, req.Verify(pdr), "epoch mismatch: shard configuration is for epoch 2, input record has epoch 1")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var req *BlockCertificationRequest
+		require.ErrorIs(t, req.IsValid(), ErrBlockCertificationRequestIsNil)
+		require.Zero(t, req.IRRound())
+
+		req = newRequest(t, "1", newIR(2))
+		req.Version = 2
+		require.ErrorContains(t, req.IsValid(), "invalid version")
+
+		req = newRequest(t, "1", newIR(2))
+		req.PartitionID = 0
+		require.EqualError(t, req.IsValid(), "invalid partition identifier: 00000000")
+
+		req = newRequest(t, "1", newIR(2))
+		req.NodeID = ""
+		require.EqualError(t, req.IsValid(), "node identifier is empty")
+
+		req = newRequest(t, "1", newIR(2))
+		req.InputRecord = nil
+		require.ErrorIs(t, req.IsValid(), ErrInputRecordIsNil)
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		req := newRequest(t, "1", newIR(2))
+		req.ShardID, _ = ShardID{}.Split()
+		data, err := req.MarshalCBOR()
+		require.NoError(t, err)
+
+		req2 := &BlockCertificationRequest{}
+		require.NoError(t, Cbor.Unmarshal(data, req2))
+		require.Equal(t, req, req2)
+
+		require.ErrorContains(t, Cbor.Unmarshal(data, &UnicitySeal{}), "expected tag")
+		sealData, err := (&UnicitySeal{Version: 1}).MarshalCBOR()
+		require.NoError(t, err)
+		require.ErrorContains(t, Cbor.Unmarshal(sealData, req2), "decoding block certification request: ")
+	})
+
+	t.Run("quorum", func(t *testing.T) {
+		ir, status, err := CheckCertificationQuorum(pdr, nil)
+		require.NoError(t, err)
+		require.Equal(t, QuorumInProgress, status)
+		require.Nil(t, ir)
+
+		reqs := []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), newRequest(t, "2", newIR(2))}
+		_, status, err = CheckCertificationQuorum(pdr, reqs)
+		require.NoError(t, err)
+		require.Equal(t, QuorumInProgress, status)
+
+		reqs = append(reqs, newRequest(t, "3", newIR(2)))
+		ir, status, err = CheckCertificationQuorum(pdr, reqs)
+		require.NoError(t, err)
+		require.Equal(t, QuorumAchieved, status)
+		require.Equal(t, reqs[0].InputRecord, ir)
+
+		// two validators disagree, quorum (3 out of 4) is not possible
+		reqs = []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), newRequest(t, "2", newIR(7)), newRequest(t, "3", newIR(8))}
+		_, status, err = CheckCertificationQuorum(pdr, reqs)
+		require.NoError(t, err)
+		require.Equal(t, QuorumNotPossible, status)
+
+		// one validator disagrees, the last one decides
+		reqs = []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), newRequest(t, "2", newIR(7)), newRequest(t, "3", newIR(2))}
+		_, status, err = CheckCertificationQuorum(pdr, reqs)
+		require.NoError(t, err)
+		require.Equal(t, QuorumInProgress, status)
+		_, status, err = CheckCertificationQuorum(pdr, append(reqs, newRequest(t, "4", newIR(2))))
+		require.NoError(t, err)
+		require.Equal(t, QuorumAchieved, status)
+	})
+
+	t.Run("quorum with unequal stakes", func(t *testing.T) {
+		pdr := *pdr
+		pdr.Validators = []*NodeInfo{
+			{NodeID: "1", SigKey: keys["1"].publicKey, Stake: 10},
+			{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 1},
+			{NodeID: "3", SigKey: keys["3"].publicKey, Stake: 1},
+		}
+		ir, status, err := CheckCertificationQuorum(&pdr, []*BlockCertificationRequest{newRequest(t, "1", newIR(2))})
+		require.NoError(t, err)
+		require.Equal(t, QuorumAchieved, status)
+		require.NotNil(t, ir)
+
+		_, status, err = CheckCertificationQuorum(&pdr, []*BlockCertificationRequest{newRequest(t, "2", newIR(2)), newRequest(t, "3", newIR(2))})
+		require.NoError(t, err)
+		require.Equal(t, QuorumInProgress, status)
+	})
+
+	t.Run("invalid request set", func(t *testing.T) {
+		_, _, err := CheckCertificationQuorum(nil, nil)
+		require.ErrorIs(t, err, ErrSystemDescriptionIsNil)
+
+		reqs := []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), newRequest(t, "1", newIR(2))}
+		_, _, err = CheckCertificationQuorum(pdr, reqs)
+		require.EqualError(t, err, `request 1: duplicate request from node "1"`)
+
+		ir := newIR(2)
+		ir.RoundNumber = 6
+		reqs = []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), newRequest(t, "2", ir)}
+		_, _, err = CheckCertificationQuorum(pdr, reqs)
+		require.EqualError(t, err, "request 1: round 6 differs from the round 5 of the first request")
+
+		reqs = []*BlockCertificationRequest{newRequest(t, "1", newIR(2)), nil}
+		_, _, err = CheckCertificationQuorum(pdr, reqs)
+		require.ErrorIs(t, err, ErrBlockCertificationRequestIsNil)
+	})
+}
+
+func TestQuorumStatus_String(t *testing.T) {
+	require.Equal(t, "in progress", QuorumInProgress.String())
+	require.Equal(t, "achieved", QuorumAchieved.String())
+	require.Equal(t, "not possible", QuorumNotPossible.String())
+	require.Equal(t, "QuorumStatus(0)", QuorumStatus(0).String())
+}
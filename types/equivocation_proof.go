@@ -0,0 +1,180 @@
+package types
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+)
+
+const (
+	// EquivocationDifferentIR - certificates for the same partition round have different input records.
+	EquivocationDifferentIR EquivocationRule = iota + 1
+	// EquivocationRoundRegression - certificate from newer root round certifies older partition round.
+	EquivocationRoundRegression
+	// EquivocationBrokenStateChain - certificate of the next partition round does not extend the previous state hash.
+	EquivocationBrokenStateChain
+	// EquivocationNonEmptyBlock - state hash does not change but block is not empty.
+	EquivocationNonEmptyBlock
+	// EquivocationEmptyBlock - state hash changes but block is empty.
+	EquivocationEmptyBlock
+	// EquivocationRepeatedBlockHash - non-empty block hash is repeated in non-repeat certificate.
+	EquivocationRepeatedBlockHash
+)
+
+type (
+	// EquivocationRule identifies the rule of the "Checking two UC-s for equivocation" algorithm.
+	EquivocationRule uint8
+
+	/*
+		EquivocationProof is an evidence of two equivocating unicity certificates of
+		the same partition shard. The proof is self-contained, anyone having the trust
+		bases of the root chain can verify it.
+	*/
+	EquivocationProof struct {
+		_       struct{}            `cbor:",toarray"`
+		Version ABVersion           `json:"version"`
+		Rule    EquivocationRule    `json:"rule"`
+		First   *UnicityCertificate `json:"first"`  // certificate from older root round
+		Second  *UnicityCertificate `json:"second"` // certificate which equivocates with the First
+	}
+)
+
+/*
+NewEquivocationProof returns proof of equivocation of the certificates "a" and "b"
+(order of the certificates is not important). Error is returned when the
+certificates do not equivocate.
+*/
+func NewEquivocationProof(a, b *UnicityCertificate) (*EquivocationProof, error) {
+	if a == nil || b == nil {
+		return nil, ErrUnicityCertificateIsNil
+	}
+	if a.GetPartitionID() != b.GetPartitionID() || !a.GetShardID().Equal(b.GetShardID()) {
+		return nil, errors.New("certificates are not of the same partition shard")
+	}
+	if b.GetRootRoundNumber() < a.GetRootRoundNumber() ||
+		(b.GetRootRoundNumber() == a.GetRootRoundNumber() && b.GetRoundNumber() < a.GetRoundNumber()) {
+		a, b = b, a
+	}
+	rule, err := checkEquivocation(a, b)
+	if rule == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("certificates are not equivocating: %w", err)
+		}
+		return nil, errors.New("certificates are not equivocating")
+	}
+	return &EquivocationProof{
+		Version: 1,
+		Rule:    rule,
+		First:   a,
+		Second:  b,
+	}, nil
+}
+
+func (p *EquivocationProof) IsValid() error {
+	if p == nil {
+		return errors.New("equivocation proof is nil")
+	}
+	if p.Version != 1 {
+		return ErrInvalidVersion(p)
+	}
+	if p.Rule < EquivocationDifferentIR || p.Rule > EquivocationRepeatedBlockHash {
+		return fmt.Errorf("unknown equivocation rule %d", p.Rule)
+	}
+	if p.First == nil || p.Second == nil {
+		return ErrUnicityCertificateIsNil
+	}
+	if p.First.UnicityTreeCertificate == nil || p.Second.UnicityTreeCertificate == nil {
+		return ErrUnicityTreeCertificateIsNil
+	}
+	if p.First.GetPartitionID() != p.Second.GetPartitionID() || !p.First.GetShardID().Equal(p.Second.GetShardID()) {
+		return errors.New("certificates are not of the same partition shard")
+	}
+	return nil
+}
+
+/*
+Verify checks that both certificates are valid (signed by the quorum of the root
+nodes of the trust base of the epoch of the certificate) and that they violate
+the rule stated in the proof.
+
+The certificates are not checked against the shard configuration, the shard
+configuration hash is part of the certified data so the certificates are bound
+to the shard configuration they were issued for. The equivocating certificates
+might be of different shard epochs.
+*/
+func (p *EquivocationProof) Verify(trustBase TrustBaseLookup, algorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := p.IsValid(); err != nil {
+		return fmt.Errorf("invalid equivocation proof: %w", err)
+	}
+	if err := verifyEquivocatingUC(p.First, trustBase, algorithm, opts); err != nil {
+		return fmt.Errorf("verifying first certificate: %w", err)
+	}
+	if err := verifyEquivocatingUC(p.Second, trustBase, algorithm, opts); err != nil {
+		return fmt.Errorf("verifying second certificate: %w", err)
+	}
+	rule, err := checkEquivocation(p.First, p.Second)
+	if rule == 0 {
+		if err != nil {
+			return fmt.Errorf("certificates are not equivocating: %w", err)
+		}
+		return errors.New("certificates are not equivocating")
+	}
+	if rule != p.Rule {
+		return fmt.Errorf("certificates violate rule %s, proof claims %s", rule, p.Rule)
+	}
+	return nil
+}
+
+func verifyEquivocatingUC(uc *UnicityCertificate, trustBase TrustBaseLookup, algorithm crypto.Hash, opts []VerifyOption) error {
+	if uc.UnicitySeal == nil {
+		return ErrUnicitySealIsNil
+	}
+	tb, err := trustBase(uc.UnicitySeal.Epoch)
+	if err != nil {
+		return fmt.Errorf("acquiring trust base of epoch %d: %w", uc.UnicitySeal.Epoch, err)
+	}
+	// nil shard configuration hash: see EquivocationProof.Verify
+	return uc.Verify(tb, algorithm, uc.GetPartitionID(), nil, opts...)
+}
+
+func (r EquivocationRule) String() string {
+	switch r {
+	case EquivocationDifferentIR:
+		return "different input records"
+	case EquivocationRoundRegression:
+		return "partition round regression"
+	case EquivocationBrokenStateChain:
+		return "broken state hash chain"
+	case EquivocationNonEmptyBlock:
+		return "state unchanged but block not empty"
+	case EquivocationEmptyBlock:
+		return "state changed but block empty"
+	case EquivocationRepeatedBlockHash:
+		return "repeated block hash"
+	default:
+		return fmt.Sprintf("EquivocationRule(%d)", uint8(r))
+	}
+}
+
+func (p *EquivocationProof) GetVersion() ABVersion {
+	if p != nil && p.Version > 0 {
+		return p.Version
+	}
+	return 1
+}
+
+func (p *EquivocationProof) MarshalCBOR() ([]byte, error) {
+	type alias EquivocationProof
+	if p.Version == 0 {
+		p.Version = p.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(EquivocationProofTag, (*alias)(p))
+}
+
+func (p *EquivocationProof) UnmarshalCBOR(data []byte) error {
+	type alias EquivocationProof
+	if err := Cbor.UnmarshalTaggedValue(EquivocationProofTag, data, (*alias)(p)); err != nil {
+		return fmt.Errorf("failed to unmarshal equivocation proof: %w", err)
+	}
+	return EnsureVersion(p, p.Version, 1)
+}
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"crypto"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestEquivocationProof(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	trustBase := func(epoch uint64) (RootTrustBase, error) { return tb, nil }
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: 0x01020304,
+		TypeIDLen:   8,
+		UnitIDLen:   256,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	newIR := func(round uint64, prevHash, hash, blockHash []byte) *InputRecord {
+		return &InputRecord{
+			Version:      1,
+			RoundNumber:  round,
+			PreviousHash: prevHash,
+			Hash:         hash,
+			BlockHash:    blockHash,
+			SummaryValue: []byte{0, 0, 4},
+			Timestamp:    NewTimestamp(),
+		}
+	}
+	trHash := make([]byte, 32)
+	uc5 := createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{2}, []byte{3}), trHash, pdr)
+
+	t.Run("same round different IR", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{9}, []byte{3}), trHash, pdr)
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.NoError(t, err)
+		require.Equal(t, EquivocationDifferentIR, proof.Rule)
+		require.NoError(t, proof.Verify(trustBase, crypto.SHA256))
+
+		// rule must match
+		proof.Rule = EquivocationRepeatedBlockHash
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "certificates violate rule different input records, proof claims repeated block hash")
+	})
+
+	t.Run("broken state hash chain", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(6, []byte{7}, []byte{8}, []byte{9}), trHash, pdr)
+		// order of the arguments doesn't matter
+		proof, err := NewEquivocationProof(ucB, uc5)
+		require.NoError(t, err)
+		require.Equal(t, EquivocationBrokenStateChain, proof.Rule)
+		require.Equal(t, uc5, proof.First)
+		require.NoError(t, proof.Verify(trustBase, crypto.SHA256))
+	})
+
+	t.Run("repeated block hash", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(6, []byte{2}, []byte{4}, []byte{3}), trHash, pdr)
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.NoError(t, err)
+		require.Equal(t, EquivocationRepeatedBlockHash, proof.Rule)
+		require.NoError(t, proof.Verify(trustBase, crypto.SHA256))
+	})
+
+	t.Run("not equivocating", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(6, []byte{2}, []byte{4}, []byte{5}), trHash, pdr)
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.EqualError(t, err, "certificates are not equivocating")
+		require.Nil(t, proof)
+
+		// forged proof
+		proof = &EquivocationProof{Version: 1, Rule: EquivocationDifferentIR, First: uc5, Second: ucB}
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "certificates are not equivocating")
+
+		otherPDR := *pdr
+		otherPDR.PartitionID++
+		ucB = createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{9}, []byte{3}), trHash, &otherPDR)
+		proof, err = NewEquivocationProof(uc5, ucB)
+		require.EqualError(t, err, "certificates are not of the same partition shard")
+		require.Nil(t, proof)
+	})
+
+	t.Run("invalid proof", func(t *testing.T) {
+		var proof *EquivocationProof
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "invalid equivocation proof: equivocation proof is nil")
+
+		proof = &EquivocationProof{Version: 2}
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "invalid equivocation proof: invalid version (type *types.EquivocationProof)")
+
+		proof = &EquivocationProof{Version: 1, Rule: 0}
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "invalid equivocation proof: unknown equivocation rule 0")
+
+		proof = &EquivocationProof{Version: 1, Rule: EquivocationDifferentIR, First: uc5}
+		require.EqualError(t, proof.Verify(trustBase, crypto.SHA256), "invalid equivocation proof: unicity certificate is nil")
+	})
+
+	t.Run("certificate not signed by trust base", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{9}, []byte{3}), trHash, pdr)
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.NoError(t, err)
+
+		_, otherVerifier := testsig.CreateSignerAndVerifier(t)
+		otherTrustBase := func(epoch uint64) (RootTrustBase, error) { return NewTrustBase(t, otherVerifier), nil }
+		require.ErrorContains(t, proof.Verify(otherTrustBase, crypto.SHA256), "verifying first certificate: verifying unicity seal")
+
+		noTrustBase := func(epoch uint64) (RootTrustBase, error) { return nil, fmt.Errorf("unknown epoch %d", epoch) }
+		require.EqualError(t, proof.Verify(noTrustBase, crypto.SHA256), "verifying first certificate: acquiring trust base of epoch 0: unknown epoch 0")
+	})
+
+	t.Run("certificates of different epochs", func(t *testing.T) {
+		signer1, verifier1 := testsig.CreateSignerAndVerifier(t)
+		tb1 := NewTrustBase(t, verifier1)
+		ucB := createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{9}, []byte{3}), trHash, pdr)
+		ucB.UnicitySeal.Epoch = 1
+		ucB.UnicitySeal.Signatures = nil
+		require.NoError(t, ucB.UnicitySeal.Sign("test", signer1))
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.NoError(t, err)
+
+		trustBases := func(epoch uint64) (RootTrustBase, error) {
+			return []RootTrustBase{tb, tb1}[epoch], nil
+		}
+		require.NoError(t, proof.Verify(trustBases, crypto.SHA256))
+		// second certificate is not signed by the trust base of the epoch 0
+		require.ErrorContains(t, proof.Verify(trustBase, crypto.SHA256), "verifying second certificate: verifying unicity seal")
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		ucB := createUnicityCertificate(t, "test", signer, newIR(5, []byte{1}, []byte{9}, []byte{3}), trHash, pdr)
+		proof, err := NewEquivocationProof(uc5, ucB)
+		require.NoError(t, err)
+
+		data, err := Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &EquivocationProof{}
+		require.NoError(t, Cbor.Unmarshal(data, proof2))
+		require.Equal(t, proof, proof2)
+		require.NoError(t, proof2.Verify(trustBase, crypto.SHA256))
+
+		proof.Version = 2
+		data, err = Cbor.Marshal(proof)
+		require.NoError(t, err)
+		require.ErrorContains(t, Cbor.Unmarshal(data, proof2), "invalid version (type *types.EquivocationProof), expected 1, got 2")
+	})
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrNetworkMismatch = errors.New("network mismatch")
+
+type (
+	// VerifyOption configures certificate and proof verification.
+	VerifyOption func(o *verifyOptions)
+
+	verifyOptions struct {
+		// networks accepted by the verifier, when empty the network of the trust base is the only accepted network
+		networks []NetworkID
+	}
+)
+
+/*
+WithAcceptedNetworks makes the verification to accept certificates of any of the
+given networks instead of requiring the network of the certificate to match the
+network of the trust base. Meant for verifiers which intentionally share the
+trust base between multiple networks (ie test networks).
+*/
+func WithAcceptedNetworks(networks ...NetworkID) VerifyOption {
+	return func(o *verifyOptions) {
+		o.networks = append(o.networks, networks...)
+	}
+}
+
+func newVerifyOptions(opts []VerifyOption) *verifyOptions {
+	o := &verifyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+/*
+checkNetwork checks that the certificate issued in the network "id" is acceptable
+when verified against the trust base "tb".
+*/
+func (o *verifyOptions) checkNetwork(id NetworkID, tb RootTrustBase) error {
+	if len(o.networks) != 0 {
+		if !slices.Contains(o.networks, id) {
+			return fmt.Errorf("%w: network %d is not accepted, accepted networks are %v", ErrNetworkMismatch, id, o.networks)
+		}
+		return nil
+	}
+	if tbNetwork := tb.GetNetworkID(); id != tbNetwork {
+		return fmt.Errorf("%w: expected network %d (trust base), got %d", ErrNetworkMismatch, tbNetwork, id)
+	}
+	return nil
+}
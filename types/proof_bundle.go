@@ -0,0 +1,156 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+)
+
+var ErrProofBundleIsNil = errors.New("proof bundle is nil")
+
+/*
+ProofBundle is a self-contained package of transaction proofs together with the
+trust material needed to verify them: the chain of root trust base entries
+starting from the genesis entry and the shard configurations (PDRs) the proofs
+were certified with. The only input required to verify the bundle is the hash
+of the genesis trust base, see ProofBundle.Verify.
+*/
+type ProofBundle struct {
+	_          struct{}                      `cbor:",toarray"`
+	Version    ABVersion                     `json:"version"`
+	TrustBases []*RootTrustBaseV1            `json:"trustBases"` // trust base entries from genesis up to the epoch of the latest UC
+	ShardConfs []*PartitionDescriptionRecord `json:"shardConfs"` // shard configurations the proofs were certified with
+	Proofs     []*TxRecordProof              `json:"proofs"`
+}
+
+/*
+Verify checks that:
+  - the first trust base entry hashes to "genesisTrustBaseHash" and each following
+    entry extends the previous one and is signed by the quorum of the previous epoch.
+    Trust base entries are always hashed with SHA256 (see NextEpoch), the
+    "hashAlgorithm" is the hash algorithm of the proofs;
+  - each proof is a proof of successfully executed transaction (see VerifyTxProof),
+    certified by the UC signed by the trust base of the UC's epoch and for the shard
+    configuration of the bundle (see VerifyTxInclusionWithShardConf).
+*/
+func (b *ProofBundle) Verify(genesisTrustBaseHash []byte, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := b.IsValid(); err != nil {
+		return fmt.Errorf("invalid proof bundle: %w", err)
+	}
+
+	genesisHash, err := b.TrustBases[0].Hash(crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("hashing genesis trust base: %w", err)
+	}
+	if !bytes.Equal(genesisHash, genesisTrustBaseHash) {
+		return fmt.Errorf("genesis trust base hash %X does not match expected hash %X", genesisHash, genesisTrustBaseHash)
+	}
+	for i := 1; i < len(b.TrustBases); i++ {
+		if err := b.TrustBases[i].Verify(b.TrustBases[i-1]); err != nil {
+			return fmt.Errorf("verifying trust base of epoch %d: %w", b.TrustBases[i].Epoch, err)
+		}
+	}
+
+	for i, proof := range b.Proofs {
+		if err := b.verifyProof(proof, hashAlgorithm, opts...); err != nil {
+			return fmt.Errorf("verifying proof %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (b *ProofBundle) verifyProof(proof *TxRecordProof, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := proof.IsValid(); err != nil {
+		return err
+	}
+	uc, err := proof.TxProof.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate: %w", err)
+	}
+	if uc.UnicitySeal == nil {
+		return ErrUnicitySealIsNil
+	}
+	tb, err := b.trustBase(uc.UnicitySeal.Epoch)
+	if err != nil {
+		return err
+	}
+	if err := VerifyTxInclusionWithShardConf(proof, tb, b.shardConf, hashAlgorithm, opts...); err != nil {
+		return fmt.Errorf("verify tx inclusion: %w", err)
+	}
+	if !proof.TxRecord.IsSuccessful() {
+		return errors.New("transaction failed")
+	}
+	return nil
+}
+
+// trustBase returns the trust base entry of the epoch, the chain of entries must have been verified.
+func (b *ProofBundle) trustBase(epoch uint64) (*RootTrustBaseV1, error) {
+	first := b.TrustBases[0].Epoch
+	if epoch < first || epoch-first >= uint64(len(b.TrustBases)) {
+		return nil, fmt.Errorf("bundle does not contain trust base of epoch %d", epoch)
+	}
+	return b.TrustBases[epoch-first], nil
+}
+
+// shardConf implements ShardConfLookup over the shard configurations of the bundle.
+func (b *ProofBundle) shardConf(partition PartitionID, shard ShardID, epoch uint64) (*PartitionDescriptionRecord, error) {
+	for _, pdr := range b.ShardConfs {
+		if pdr.PartitionID == partition && pdr.ShardID.Key() == shard.Key() && pdr.Epoch == epoch {
+			return pdr, nil
+		}
+	}
+	return nil, errors.New("bundle does not contain the shard configuration")
+}
+
+func (b *ProofBundle) IsValid() error {
+	if b == nil {
+		return ErrProofBundleIsNil
+	}
+	if b.Version != 1 {
+		return ErrInvalidVersion(b)
+	}
+	if len(b.TrustBases) == 0 {
+		return errors.New("no trust bases")
+	}
+	for i, tb := range b.TrustBases {
+		if tb == nil {
+			return fmt.Errorf("trust base %d is nil", i)
+		}
+	}
+	if len(b.ShardConfs) == 0 {
+		return errors.New("no shard configurations")
+	}
+	for i, pdr := range b.ShardConfs {
+		if pdr == nil {
+			return fmt.Errorf("shard configuration %d is nil", i)
+		}
+	}
+	if len(b.Proofs) == 0 {
+		return errors.New("no proofs")
+	}
+	return nil
+}
+
+func (b *ProofBundle) GetVersion() ABVersion {
+	if b != nil && b.Version > 0 {
+		return b.Version
+	}
+	return 1
+}
+
+func (b *ProofBundle) MarshalCBOR() ([]byte, error) {
+	type alias ProofBundle
+	if b.Version == 0 {
+		b.Version = b.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(ProofBundleTag, (*alias)(b))
+}
+
+func (b *ProofBundle) UnmarshalCBOR(data []byte) error {
+	type alias ProofBundle
+	if err := Cbor.UnmarshalTaggedValue(ProofBundleTag, data, (*alias)(b)); err != nil {
+		return err
+	}
+	return EnsureVersion(b, b.Version, 1)
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestProofBundle(t *testing.T) {
+	signer1, verifier1 := testsig.CreateSignerAndVerifier(t)
+	signer2, verifier2 := testsig.CreateSignerAndVerifier(t)
+	key1, err := verifier1.MarshalPublicKey()
+	require.NoError(t, err)
+	key2, err := verifier2.MarshalPublicKey()
+	require.NoError(t, err)
+
+	genesis, err := NewTrustBaseGenesis(NetworkMainNet, []*NodeInfo{{NodeID: "test", SigKey: key1, Stake: 1}})
+	require.NoError(t, err)
+	genesisHash, err := genesis.Hash(crypto.SHA256)
+	require.NoError(t, err)
+	epoch2, err := NextEpoch(genesis, []*NodeInfo{{NodeID: "test2", SigKey: key2, Stake: 1}}, "change record", WithEpochStartRound(100))
+	require.NoError(t, err)
+	require.NoError(t, epoch2.Sign("test", signer1))
+
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: partitionID,
+		TypeIDLen:   8,
+		UnitIDLen:   248,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	// proof certified in the genesis epoch and in the second epoch
+	proof1 := createEpochTxProof(t, "test", signer1, 1, pdr)
+	proof2 := createEpochTxProof(t, "test2", signer2, 2, pdr)
+
+	newBundle := func() *ProofBundle {
+		return &ProofBundle{
+			Version:    1,
+			TrustBases: []*RootTrustBaseV1{genesis, epoch2},
+			ShardConfs: []*PartitionDescriptionRecord{pdr},
+			Proofs:     []*TxRecordProof{proof1, proof2},
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		bundle := newBundle()
+		require.NoError(t, bundle.Verify(genesisHash, crypto.SHA256))
+
+		data, err := Cbor.Marshal(bundle)
+		require.NoError(t, err)
+		bundle2 := &ProofBundle{}
+		require.NoError(t, Cbor.Unmarshal(data, bundle2))
+		require.NoError(t, bundle2.Verify(genesisHash, crypto.SHA256))
+
+		// bundle of the genesis epoch proofs doesn't need the later epochs
+		bundle = &ProofBundle{Version: 1, TrustBases: []*RootTrustBaseV1{genesis}, ShardConfs: []*PartitionDescriptionRecord{pdr}, Proofs: []*TxRecordProof{proof1}}
+		require.NoError(t, bundle.Verify(genesisHash, crypto.SHA256))
+	})
+
+	t.Run("unexpected genesis", func(t *testing.T) {
+		require.ErrorContains(t, newBundle().Verify(make([]byte, 32), crypto.SHA256), "genesis trust base hash")
+		// trust base chain is hashed with SHA256 regardless of the hash algorithm of the proofs
+		err := newBundle().Verify(genesisHash, crypto.SHA512)
+		require.ErrorContains(t, err, "verifying proof 0")
+	})
+
+	t.Run("invalid trust base chain", func(t *testing.T) {
+		unsigned, err := NextEpoch(genesis, []*NodeInfo{{NodeID: "test2", SigKey: key2, Stake: 1}}, "change record", WithEpochStartRound(100))
+		require.NoError(t, err)
+		bundle := newBundle()
+		bundle.TrustBases[1] = unsigned
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256),
+			"verifying trust base of epoch 2: quorum not reached, signed_votes=0 quorum_threshold=1")
+	})
+
+	t.Run("missing trust base", func(t *testing.T) {
+		bundle := newBundle()
+		bundle.TrustBases = bundle.TrustBases[:1]
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256), "verifying proof 1: bundle does not contain trust base of epoch 2")
+	})
+
+	t.Run("proof signed by wrong epoch", func(t *testing.T) {
+		bundle := newBundle()
+		bundle.Proofs = []*TxRecordProof{createEpochTxProof(t, "test", signer1, 2, pdr)}
+		require.ErrorContains(t, bundle.Verify(genesisHash, crypto.SHA256), "verifying proof 0: verify tx inclusion: invalid unicity certificate")
+	})
+
+	t.Run("missing shard configuration", func(t *testing.T) {
+		bundle := newBundle()
+		other := *pdr
+		other.Epoch = 1
+		bundle.ShardConfs = []*PartitionDescriptionRecord{&other}
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256),
+			`verifying proof 0: verify tx inclusion: acquiring shard configuration of partition 01000001 shard "" epoch 0: bundle does not contain the shard configuration`)
+	})
+
+	t.Run("failed transaction", func(t *testing.T) {
+		bundle := newBundle()
+		txr := *proof1.TxRecord
+		txr.ServerMetadata = &ServerMetadata{ActualFee: 1, SuccessIndicator: TxStatusFailed}
+		bundle.Proofs = []*TxRecordProof{{TxRecord: &txr, TxProof: proof1.TxProof}}
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256), "verifying proof 0: verify tx inclusion: proof block hash does not match to block hash in unicity certificate")
+
+		failed := createTransactionRecord(t, createTransactionOrder(t), 1)
+		failed.ServerMetadata.SuccessIndicator = TxStatusFailed
+		block := createBlockWithPDR(t, "test", signer1, pdr, failed)
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		setSealEpoch(t, proof, "test", signer1, 1)
+		bundle.Proofs = []*TxRecordProof{proof}
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256), "verifying proof 0: transaction failed")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var bundle *ProofBundle
+		require.ErrorIs(t, bundle.IsValid(), ErrProofBundleIsNil)
+
+		bundle = newBundle()
+		bundle.Version = 2
+		require.ErrorContains(t, bundle.IsValid(), "invalid version")
+
+		bundle = newBundle()
+		bundle.TrustBases = nil
+		require.EqualError(t, bundle.IsValid(), "no trust bases")
+		bundle.TrustBases = []*RootTrustBaseV1{nil}
+		require.EqualError(t, bundle.IsValid(), "trust base 0 is nil")
+
+		bundle = newBundle()
+		bundle.ShardConfs = nil
+		require.EqualError(t, bundle.IsValid(), "no shard configurations")
+		bundle.ShardConfs = []*PartitionDescriptionRecord{nil}
+		require.EqualError(t, bundle.IsValid(), "shard configuration 0 is nil")
+
+		bundle = newBundle()
+		bundle.Proofs = nil
+		require.EqualError(t, bundle.IsValid(), "no proofs")
+		require.EqualError(t, bundle.Verify(genesisHash, crypto.SHA256), "invalid proof bundle: no proofs")
+	})
+}
+
+// createEpochTxProof returns proof of a transaction certified by the UC signed by "signer" in the root "epoch".
+func createEpochTxProof(t *testing.T, rootID string, signer abcrypto.Signer, epoch uint64, pdr *PartitionDescriptionRecord) *TxRecordProof {
+	t.Helper()
+	block := createBlockWithPDR(t, rootID, signer, pdr, createTx(t))
+	proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+	require.NoError(t, err)
+	setSealEpoch(t, proof, rootID, signer, epoch)
+	return proof
+}
+
+func setSealEpoch(t *testing.T, proof *TxRecordProof, rootID string, signer abcrypto.Signer, epoch uint64) {
+	t.Helper()
+	uc, err := proof.TxProof.GetUC()
+	require.NoError(t, err)
+	uc.UnicitySeal.Epoch = epoch
+	uc.UnicitySeal.Signatures = nil
+	require.NoError(t, uc.UnicitySeal.Sign(rootID, signer))
+	proof.TxProof.UnicityCertificate, err = uc.MarshalCBOR()
+	require.NoError(t, err)
+}
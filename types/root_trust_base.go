@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"cmp"
 	"crypto"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
 	abhash "github.com/alphabill-org/alphabill-go-base/hash"
 	"github.com/alphabill-org/alphabill-go-base/types/hex"
+	"github.com/alphabill-org/alphabill-go-base/util"
 )
 
 type (
@@ -30,7 +32,7 @@ type (
 		Epoch             uint64               `json:"epoch"`             // current epoch number
 		EpochStartRound   uint64               `json:"epochStartRound"`   // root chain round number when the epoch begins
 		RootNodes         []*NodeInfo          `json:"rootNodes"`         // list of all root nodes for the current epoch
-		QuorumThreshold   uint64               `json:"quorumThreshold"`   // amount of alpha required to reach consensus, currently each node gets equal amount of voting power i.e. +1 for each node
+		QuorumThreshold   uint64               `json:"quorumThreshold"`   // amount of staked alpha required to reach consensus
 		StateHash         hex.Bytes            `json:"stateHash"`         // unicity tree root hash
 		ChangeRecordHash  hex.Bytes            `json:"changeRecordHash"`  // epoch change request hash
 		PreviousEntryHash hex.Bytes            `json:"previousEntryHash"` // previous trust base entry hash
@@ -52,6 +54,8 @@ type (
 
 	trustBaseConf struct {
 		quorumThreshold uint64
+		epochStartRound uint64
+		stateHash       []byte
 	}
 )
 
@@ -67,27 +71,10 @@ func NewTrustBaseGenesis(networkID NetworkID, rootNodes []*NodeInfo, opts ...Opt
 		opt(c)
 	}
 
-	// Sort rootNodes by NodeID, so that we have a consistent order in every
-	// implementation/encoding and we can perform binary search on them.
-	slices.SortFunc(rootNodes, func(a, b *NodeInfo) int {
-		return cmp.Compare(a.NodeID, b.NodeID)
-	})
-
-	// calculate quorum threshold
-	var totalStake uint64
-	for _, n := range rootNodes {
-		totalStake += n.Stake
-	}
-	minStake := totalStake*2/3 + 1
-
-	if c.quorumThreshold == 0 {
-		c.quorumThreshold = minStake // set quorum threshold to minimum if no threshold was configured
-	}
-	if c.quorumThreshold < minStake {
-		return nil, fmt.Errorf("quorum threshold must be at least '2/3+1' (min threshold %d got %d)", minStake, c.quorumThreshold)
-	}
-	if c.quorumThreshold > totalStake {
-		return nil, fmt.Errorf("quorum threshold cannot exceed the total staked amount (max threshold %d got %d)", totalStake, c.quorumThreshold)
+	sortNodes(rootNodes)
+	quorumThreshold, err := c.calculateQuorumThreshold(rootNodes)
+	if err != nil {
+		return nil, err
 	}
 
 	return &RootTrustBaseV1{
@@ -96,7 +83,7 @@ func NewTrustBaseGenesis(networkID NetworkID, rootNodes []*NodeInfo, opts ...Opt
 		Epoch:             1,
 		EpochStartRound:   1,
 		RootNodes:         rootNodes,
-		QuorumThreshold:   c.quorumThreshold,
+		QuorumThreshold:   quorumThreshold,
 		StateHash:         nil,
 		ChangeRecordHash:  nil,
 		PreviousEntryHash: nil,
@@ -104,6 +91,133 @@ func NewTrustBaseGenesis(networkID NetworkID, rootNodes []*NodeInfo, opts ...Opt
 	}, nil
 }
 
+/*
+NextEpoch creates new unsigned root trust base entry which follows the "current" entry.
+
+The "changeRecord" is the epoch change record which caused the transition, it's
+hash is stored in the ChangeRecordHash field of the new entry.
+The epoch start round must be set using [WithEpochStartRound] option.
+The "current" entry must be final (signed) as it's hash, including the
+signatures, is stored in the PreviousEntryHash field of the new entry.
+
+The new entry becomes valid once root nodes of the current epoch holding at
+least quorum threshold of the current epoch stake have signed it, see
+[RootTrustBaseV1.Sign] and [RootTrustBaseV1.HasQuorum].
+*/
+func NextEpoch(current *RootTrustBaseV1, newRootNodes []*NodeInfo, changeRecord any, opts ...Option) (*RootTrustBaseV1, error) {
+	if current == nil {
+		return nil, errors.New("current trust base entry is nil")
+	}
+	if len(newRootNodes) == 0 {
+		return nil, errors.New("nodes list is empty")
+	}
+
+	c := &trustBaseConf{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.epochStartRound <= current.EpochStartRound {
+		return nil, fmt.Errorf("epoch start round must be greater than the start round of the current epoch %d, got %d", current.EpochStartRound, c.epochStartRound)
+	}
+
+	for i, n := range newRootNodes {
+		if err := n.IsValid(); err != nil {
+			return nil, fmt.Errorf("invalid root node at idx %d: %w", i, err)
+		}
+	}
+	sortNodes(newRootNodes)
+	for i := 1; i < len(newRootNodes); i++ {
+		if newRootNodes[i-1].NodeID == newRootNodes[i].NodeID {
+			return nil, fmt.Errorf("duplicate root node %q", newRootNodes[i].NodeID)
+		}
+	}
+	quorumThreshold, err := c.calculateQuorumThreshold(newRootNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	prevHash, err := current.Hash(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("hashing current trust base entry: %w", err)
+	}
+	changeRecordHash, err := HashCBOR(changeRecord, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("hashing change record: %w", err)
+	}
+
+	return &RootTrustBaseV1{
+		Version:           1,
+		NetworkID:         current.NetworkID,
+		Epoch:             current.Epoch + 1,
+		EpochStartRound:   c.epochStartRound,
+		RootNodes:         newRootNodes,
+		QuorumThreshold:   quorumThreshold,
+		StateHash:         c.stateHash,
+		ChangeRecordHash:  changeRecordHash,
+		PreviousEntryHash: prevHash,
+		Signatures:        make(map[string]hex.Bytes),
+	}, nil
+}
+
+/*
+calculateQuorumThreshold returns configured quorum threshold or the
+default 2/3+1 of the total stake of the nodes when threshold is not configured.
+*/
+func (c *trustBaseConf) calculateQuorumThreshold(rootNodes []*NodeInfo) (uint64, error) {
+	totalStake, err := TotalStake(rootNodes)
+	if err != nil {
+		return 0, err
+	}
+	minStake := MinQuorumThreshold(totalStake)
+
+	if c.quorumThreshold == 0 {
+		return minStake, nil // set quorum threshold to minimum if no threshold was configured
+	}
+	if c.quorumThreshold < minStake {
+		return 0, fmt.Errorf("quorum threshold must be at least '2/3+1' (min threshold %d got %d)", minStake, c.quorumThreshold)
+	}
+	if c.quorumThreshold > totalStake {
+		return 0, fmt.Errorf("quorum threshold cannot exceed the total staked amount (max threshold %d got %d)", totalStake, c.quorumThreshold)
+	}
+	return c.quorumThreshold, nil
+}
+
+// Sort rootNodes by NodeID, so that we have a consistent order in every
+// implementation/encoding and we can perform binary search on them.
+func sortNodes(rootNodes []*NodeInfo) {
+	slices.SortFunc(rootNodes, func(a, b *NodeInfo) int {
+		return cmp.Compare(a.NodeID, b.NodeID)
+	})
+}
+
+/*
+TotalStake returns the sum of the stakes of the nodes, error is returned
+when the sum overflows uint64.
+*/
+func TotalStake(nodes []*NodeInfo) (uint64, error) {
+	stakes := make([]uint64, len(nodes))
+	for i, n := range nodes {
+		if n == nil {
+			return 0, fmt.Errorf("node info at idx %d is nil", i)
+		}
+		stakes[i] = n.Stake
+	}
+	total, ok := util.AddUint64(stakes...)
+	if !ok {
+		return 0, errors.New("total stake overflows uint64")
+	}
+	return total, nil
+}
+
+/*
+MinQuorumThreshold returns the minimum amount of stake required to reach
+quorum (more than 2/3 of the total stake).
+*/
+func MinQuorumThreshold(totalStake uint64) uint64 {
+	// floor(2*total/3)+1 calculated without the intermediate overflow of 2*total
+	return 2*(totalStake/3) + (2*(totalStake%3))/3 + 1
+}
+
 // WithQuorumThreshold overrides the default 2/3+1 quorum threshold.
 func WithQuorumThreshold(threshold uint64) Option {
 	return func(c *trustBaseConf) {
@@ -111,6 +225,20 @@ func WithQuorumThreshold(threshold uint64) Option {
 	}
 }
 
+// WithEpochStartRound sets the root chain round number when the new epoch begins.
+func WithEpochStartRound(round uint64) Option {
+	return func(c *trustBaseConf) {
+		c.epochStartRound = round
+	}
+}
+
+// WithStateHash sets the unicity tree root hash of the trust base entry.
+func WithStateHash(stateHash []byte) Option {
+	return func(c *trustBaseConf) {
+		c.stateHash = stateHash
+	}
+}
+
 // IsValid validates that all fields are correctly set and public keys are correct.
 func (n *NodeInfo) IsValid() error {
 	if n == nil {
@@ -119,10 +247,8 @@ func (n *NodeInfo) IsValid() error {
 	if n.NodeID == "" {
 		return errors.New("node identifier is empty")
 	}
-	// until proper staking is implemented require that all nodes do have equal stake
-	// and thus equal vote when determining quorum
-	if n.Stake != 1 {
-		return errors.New("node must have stake == 1")
+	if n.Stake == 0 {
+		return errors.New("node must have non-zero stake")
 	}
 	if len(n.SigKey) == 0 {
 		return errors.New("signing key is empty")
@@ -160,10 +286,109 @@ func (r *RootTrustBaseV1) Sign(nodeID string, signer abcrypto.Signer) error {
 	if err != nil {
 		return fmt.Errorf("signing failed: %w", err)
 	}
+	if r.Signatures == nil {
+		r.Signatures = make(map[string]hex.Bytes)
+	}
 	r.Signatures[nodeID] = sig
 	return nil
 }
 
+/*
+HasQuorum returns true when the entry has been signed by the root nodes of the
+"prev" epoch holding at least the quorum threshold of the "prev" epoch stake.
+Invalid signatures and signatures of the nodes not in the "prev" epoch are ignored.
+
+For the genesis entry (Epoch == 1) "prev" must be nil and the signatures are
+checked against the entry itself.
+*/
+func (r *RootTrustBaseV1) HasQuorum(prev *RootTrustBaseV1) (bool, error) {
+	signedStake, threshold, err := r.signedStakeAndThreshold(prev)
+	if err != nil {
+		return false, err
+	}
+	return signedStake >= threshold, nil
+}
+
+// signedStakeAndThreshold returns the signed stake of the entry and the quorum threshold it must reach.
+func (r *RootTrustBaseV1) signedStakeAndThreshold(prev *RootTrustBaseV1) (signedStake, threshold uint64, _ error) {
+	signedStake, err := r.SignedStake(prev)
+	if err != nil {
+		return 0, 0, err
+	}
+	if prev == nil {
+		return signedStake, r.QuorumThreshold, nil
+	}
+	return signedStake, prev.QuorumThreshold, nil
+}
+
+/*
+SignedStake returns the amount of the "prev" epoch stake which has validly
+signed the entry. See [RootTrustBaseV1.HasQuorum] for the meaning of "prev".
+*/
+func (r *RootTrustBaseV1) SignedStake(prev *RootTrustBaseV1) (uint64, error) {
+	signers := prev
+	if prev == nil {
+		if r.Epoch != 1 {
+			return 0, fmt.Errorf("previous trust base entry is required for epoch %d", r.Epoch)
+		}
+		signers = r
+	} else if err := r.verifyPrevious(prev); err != nil {
+		return 0, err
+	}
+
+	sb, err := r.SigBytes()
+	if err != nil {
+		return 0, err
+	}
+	var signedStake uint64
+	for nodeID, sig := range r.Signatures {
+		if stake, err := signers.VerifySignature(sb, sig, nodeID); err == nil {
+			var ok bool
+			if signedStake, ok = util.SafeAdd(signedStake, stake); !ok {
+				return 0, errors.New("signed stake overflows uint64")
+			}
+		}
+	}
+	return signedStake, nil
+}
+
+/*
+Verify checks that the entry correctly extends the "prev" entry and that it has
+been signed by the quorum of the "prev" epoch root nodes.
+For the genesis entry "prev" must be nil.
+*/
+func (r *RootTrustBaseV1) Verify(prev *RootTrustBaseV1) error {
+	signedStake, threshold, err := r.signedStakeAndThreshold(prev)
+	if err != nil {
+		return err
+	}
+	if signedStake < threshold {
+		return fmt.Errorf("quorum not reached, signed_votes=%d quorum_threshold=%d", signedStake, threshold)
+	}
+	return nil
+}
+
+// verifyPrevious checks that the entry is the successor of the "prev" entry.
+func (r *RootTrustBaseV1) verifyPrevious(prev *RootTrustBaseV1) error {
+	if r.NetworkID != prev.NetworkID {
+		return fmt.Errorf("network ID %d does not match previous entry network ID %d", r.NetworkID, prev.NetworkID)
+	}
+	if r.Epoch != prev.Epoch+1 {
+		return fmt.Errorf("invalid epoch, expected %d got %d", prev.Epoch+1, r.Epoch)
+	}
+	if r.EpochStartRound <= prev.EpochStartRound {
+		return fmt.Errorf("invalid epoch start round %d, previous epoch started at %d", r.EpochStartRound, prev.EpochStartRound)
+	}
+	prevHash, err := prev.Hash(crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("hashing previous trust base entry: %w", err)
+	}
+	if !bytes.Equal(prevHash, r.PreviousEntryHash) {
+		return fmt.Errorf("previous entry hash %X does not match the hash of the previous entry %X", r.PreviousEntryHash, prevHash)
+	}
+	return nil
+}
+
 // Hash hashes the entire structure including the signatures.
 func (r *RootTrustBaseV1) Hash(hashAlgo crypto.Hash) ([]byte, error) {
 	hasher := abhash.New(hashAlgo.New())
@@ -188,7 +413,10 @@ func (r *RootTrustBaseV1) VerifyQuorumSignatures(data []byte, signatures map[str
 	var quorum uint64
 	for nodeID, sig := range signatures {
 		if stake, err := r.VerifySignature(data, sig, nodeID); err == nil {
-			quorum += stake
+			var ok bool
+			if quorum, ok = util.SafeAdd(quorum, stake); !ok {
+				return errors.New("signed stake overflows uint64")
+			}
 		}
 	}
 	if quorum >= r.QuorumThreshold {
@@ -219,9 +447,22 @@ func (r *RootTrustBaseV1) GetQuorumThreshold() uint64 {
 	return r.QuorumThreshold
 }
 
-// GetMaxFaultyNodes returns max allowed faulty nodes, only works if one node == one vote.
+/*
+GetMaxFaultyNodes returns the max amount of stake which may be controlled by
+faulty nodes, ie total stake minus quorum threshold. When every node has stake
+of one it equals to the max number of faulty nodes.
+*/
 func (r *RootTrustBaseV1) GetMaxFaultyNodes() uint64 {
-	return uint64(len(r.RootNodes)) - r.QuorumThreshold
+	total, err := TotalStake(r.RootNodes)
+	if err != nil || total < r.QuorumThreshold {
+		return 0
+	}
+	return total - r.QuorumThreshold
+}
+
+// GetTotalStake returns the sum of the stakes of all the root nodes of the epoch.
+func (r *RootTrustBaseV1) GetTotalStake() (uint64, error) {
+	return TotalStake(r.RootNodes)
 }
 
 func (r *RootTrustBaseV1) GetRootNodes() []*NodeInfo {
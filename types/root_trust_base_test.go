@@ -1,7 +1,9 @@
 package types
 
 import (
+	"crypto"
 	"fmt"
+	"math"
 	"strconv"
 	"testing"
 
@@ -25,9 +27,9 @@ func TestNodeInfo_IsValid(t *testing.T) {
 
 	n = validNodeInfo()
 	n.Stake = 0
-	require.EqualError(t, n.IsValid(), `node must have stake == 1`)
+	require.EqualError(t, n.IsValid(), `node must have non-zero stake`)
 	n.Stake = 2
-	require.EqualError(t, n.IsValid(), `node must have stake == 1`)
+	require.NoError(t, n.IsValid())
 
 	n = validNodeInfo()
 	n.NodeID = ""
@@ -183,6 +185,35 @@ func TestNewTrustBaseGenesis(t *testing.T) {
 			},
 			wantErrStr: fmt.Sprintf("quorum threshold cannot exceed the total staked amount (max threshold %d got %d)", 3, 4),
 		},
+		{
+			name: "unequal stakes ok",
+			args: args{
+				nodes: []*NodeInfo{
+					{NodeID: "1", SigKey: keys["1"].publicKey, Stake: 10},
+					{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 5},
+					{NodeID: "3", SigKey: keys["3"].publicKey, Stake: 3},
+					{NodeID: "4", SigKey: keys["4"].publicKey, Stake: 2},
+				},
+			},
+			verifyFunc: func(t *testing.T, tb *RootTrustBaseV1) {
+				// total stake 20, quorum floor(2*20/3)+1 = 14
+				total, err := tb.GetTotalStake()
+				require.NoError(t, err)
+				require.EqualValues(t, 20, total)
+				require.EqualValues(t, 14, tb.GetQuorumThreshold())
+				require.EqualValues(t, 6, tb.GetMaxFaultyNodes())
+			},
+		},
+		{
+			name: "total stake overflow",
+			args: args{
+				nodes: []*NodeInfo{
+					{NodeID: "1", SigKey: keys["1"].publicKey, Stake: math.MaxUint64},
+					{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 1},
+				},
+			},
+			wantErrStr: "total stake overflows uint64",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -201,6 +232,49 @@ func TestNewTrustBaseGenesis(t *testing.T) {
 	}
 }
 
+func TestMinQuorumThreshold(t *testing.T) {
+	require.EqualValues(t, 1, MinQuorumThreshold(0))
+	require.EqualValues(t, 1, MinQuorumThreshold(1))
+	require.EqualValues(t, 3, MinQuorumThreshold(3))
+	require.EqualValues(t, 3, MinQuorumThreshold(4))
+	require.EqualValues(t, 4, MinQuorumThreshold(5))
+	require.EqualValues(t, 14, MinQuorumThreshold(20))
+	// must not overflow
+	require.EqualValues(t, uint64(math.MaxUint64/3*2+1), MinQuorumThreshold(math.MaxUint64))
+}
+
+func TestVerifyQuorumSignatures_UnequalStake(t *testing.T) {
+	keys := genKeys(4)
+	tb, err := NewTrustBaseGenesis(NetworkMainNet, []*NodeInfo{
+		{NodeID: "1", SigKey: keys["1"].publicKey, Stake: 10},
+		{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 5},
+		{NodeID: "3", SigKey: keys["3"].publicKey, Stake: 3},
+		{NodeID: "4", SigKey: keys["4"].publicKey, Stake: 2},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 14, tb.QuorumThreshold)
+
+	data := []byte("data to sign")
+	sign := func(ids ...string) map[string]hex.Bytes {
+		sigs := make(map[string]hex.Bytes)
+		for _, id := range ids {
+			sig, err := keys[id].signer.SignBytes(data)
+			require.NoError(t, err)
+			sigs[id] = sig
+		}
+		return sigs
+	}
+
+	// majority of nodes but not majority of stake
+	require.EqualError(t, tb.VerifyQuorumSignatures(data, sign("2", "3", "4")), "quorum not reached, signed_votes=10 quorum_threshold=14")
+	// two nodes holding enough stake
+	require.NoError(t, tb.VerifyQuorumSignatures(data, sign("1", "2")))
+	// invalid signatures do not count
+	sigs := sign("1", "2")
+	sigs["2"] = sigs["1"]
+	require.EqualError(t, tb.VerifyQuorumSignatures(data, sigs), "quorum not reached, signed_votes=10 quorum_threshold=14")
+}
+
 func TestSignAndVerify(t *testing.T) {
 	keys := genKeys(1)
 	tb, err := NewTrustBaseGenesis(
@@ -310,3 +384,114 @@ func NewTrustBaseFromVerifiers(t *testing.T, verifiers map[string]abcrypto.Verif
 	require.NoError(t, err)
 	return tb
 }
+
+func TestNextEpoch(t *testing.T) {
+	keys := genKeys(5)
+	genesis, err := NewTrustBaseGenesis(NetworkMainNet, []*NodeInfo{
+		{NodeID: "1", SigKey: keys["1"].publicKey, Stake: 1},
+		{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 1},
+		{NodeID: "3", SigKey: keys["3"].publicKey, Stake: 1},
+	})
+	require.NoError(t, err)
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, genesis.Sign(id, keys[id].signer))
+	}
+	require.NoError(t, genesis.Verify(nil))
+
+	newNodes := func() []*NodeInfo {
+		return []*NodeInfo{
+			{NodeID: "5", SigKey: keys["5"].publicKey, Stake: 4},
+			{NodeID: "3", SigKey: keys["3"].publicKey, Stake: 1},
+			{NodeID: "4", SigKey: keys["4"].publicKey, Stake: 2},
+		}
+	}
+	changeRecord := []byte("change record")
+
+	t.Run("invalid input", func(t *testing.T) {
+		tb, err := NextEpoch(nil, newNodes(), changeRecord, WithEpochStartRound(100))
+		require.EqualError(t, err, "current trust base entry is nil")
+		require.Nil(t, tb)
+
+		tb, err = NextEpoch(genesis, nil, changeRecord, WithEpochStartRound(100))
+		require.EqualError(t, err, "nodes list is empty")
+		require.Nil(t, tb)
+
+		tb, err = NextEpoch(genesis, newNodes(), changeRecord)
+		require.EqualError(t, err, "epoch start round must be greater than the start round of the current epoch 1, got 0")
+		require.Nil(t, tb)
+
+		nodes := newNodes()
+		nodes[1].Stake = 0
+		tb, err = NextEpoch(genesis, nodes, changeRecord, WithEpochStartRound(100))
+		require.EqualError(t, err, "invalid root node at idx 1: node must have non-zero stake")
+		require.Nil(t, tb)
+
+		nodes = newNodes()
+		nodes[1].NodeID = "4"
+		tb, err = NextEpoch(genesis, nodes, changeRecord, WithEpochStartRound(100))
+		require.EqualError(t, err, `duplicate root node "4"`)
+		require.Nil(t, tb)
+
+		tb, err = NextEpoch(genesis, newNodes(), changeRecord, WithEpochStartRound(100), WithQuorumThreshold(4))
+		require.EqualError(t, err, "quorum threshold must be at least '2/3+1' (min threshold 5 got 4)")
+		require.Nil(t, tb)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		stateHash := []byte{1, 2, 3}
+		tb, err := NextEpoch(genesis, newNodes(), changeRecord, WithEpochStartRound(100), WithStateHash(stateHash))
+		require.NoError(t, err)
+
+		require.EqualValues(t, 2, tb.Epoch)
+		require.EqualValues(t, 100, tb.EpochStartRound)
+		require.Equal(t, genesis.NetworkID, tb.NetworkID)
+		require.EqualValues(t, 5, tb.QuorumThreshold)
+		require.EqualValues(t, stateHash, tb.StateHash)
+		require.Equal(t, []string{"3", "4", "5"}, []string{tb.RootNodes[0].NodeID, tb.RootNodes[1].NodeID, tb.RootNodes[2].NodeID})
+		prevHash, err := genesis.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualValues(t, prevHash, tb.PreviousEntryHash)
+		crHash, err := HashCBOR(changeRecord, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualValues(t, crHash, tb.ChangeRecordHash)
+		require.Empty(t, tb.Signatures)
+
+		// signing workflow - new entry must be signed by the nodes of the previous epoch
+		ok, err := tb.HasQuorum(genesis)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// signature of the node not in the previous epoch does not count
+		require.NoError(t, tb.Sign("5", keys["5"].signer))
+		require.NoError(t, tb.Sign("1", keys["1"].signer))
+		require.NoError(t, tb.Sign("2", keys["2"].signer))
+		stake, err := tb.SignedStake(genesis)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, stake)
+		ok, err = tb.HasQuorum(genesis)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.EqualError(t, tb.Verify(genesis), "quorum not reached, signed_votes=2 quorum_threshold=3")
+
+		require.NoError(t, tb.Sign("3", keys["3"].signer))
+		ok, err = tb.HasQuorum(genesis)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, tb.Verify(genesis))
+
+		// next epoch entry can't be verified without the previous entry
+		require.EqualError(t, tb.Verify(nil), "previous trust base entry is required for epoch 2")
+
+		// entry must extend the previous entry
+		tb2, err := NextEpoch(tb, newNodes(), changeRecord, WithEpochStartRound(200))
+		require.NoError(t, err)
+		require.EqualError(t, tb2.Verify(genesis), "invalid epoch, expected 2 got 3")
+		tb2.Epoch = 2
+		require.ErrorContains(t, tb2.Verify(genesis), "previous entry hash")
+		tb2.Epoch = 3
+		for _, id := range []string{"4", "5"} {
+			require.NoError(t, tb2.Sign(id, keys[id].signer))
+		}
+		require.NoError(t, tb2.Verify(tb))
+	})
+}
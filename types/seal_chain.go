@@ -0,0 +1,193 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSealChainGap        = errors.New("gap in the seal chain")
+	ErrSealChainFork       = errors.New("fork in the seal chain")
+	ErrSealChainRegression = errors.New("root round regression in the seal chain")
+	ErrSealChainTimestamp  = errors.New("timestamp regression in the seal chain")
+	ErrSealChainNetwork    = errors.New("network mismatch in the seal chain")
+	ErrSealChainEpoch      = errors.New("invalid epoch transition in the seal chain")
+)
+
+type (
+	/*
+		SealChainError describes the first inconsistency found in the run of
+		unicity seals. Use errors.Is with one of the ErrSealChain* errors to
+		find out the kind of the inconsistency.
+	*/
+	SealChainError struct {
+		Index int          // index of the offending seal in the run
+		Prev  *UnicitySeal // seal preceding the offending seal, nil when the first seal is invalid
+		Seal  *UnicitySeal // offending seal
+		Err   error
+	}
+
+	/*
+		SealChainVerifier verifies that a run of unicity seals forms an unbroken
+		chain of root rounds.
+	*/
+	SealChainVerifier struct {
+		trustBase TrustBaseLookup
+		networkID *NetworkID
+	}
+
+	SealChainOption func(v *SealChainVerifier)
+)
+
+func (e *SealChainError) Error() string {
+	if e.Seal == nil {
+		return fmt.Sprintf("seal chain broken at index %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("seal chain broken at index %d (root round %d): %v", e.Index, e.Seal.RootChainRoundNumber, e.Err)
+}
+
+func (e *SealChainError) Unwrap() error { return e.Err }
+
+/*
+NewSealChainVerifier returns seal chain verifier. By default only the linkage
+of the seals is verified, use WithSealTrustBase to also verify the signatures.
+*/
+func NewSealChainVerifier(opts ...SealChainOption) *SealChainVerifier {
+	v := &SealChainVerifier{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+/*
+WithSealTrustBase makes the verifier to verify the signatures of each seal
+against the trust base of the seal's epoch. When the trust base is
+RootTrustBaseV1 the seals are also checked against the epoch start round.
+*/
+func WithSealTrustBase(trustBase TrustBaseLookup) SealChainOption {
+	return func(v *SealChainVerifier) {
+		v.trustBase = trustBase
+	}
+}
+
+/*
+WithSealNetworkID makes the verifier to require the seals to be of given network,
+by default the network of the first seal in the run is used.
+*/
+func WithSealNetworkID(networkID NetworkID) SealChainOption {
+	return func(v *SealChainVerifier) {
+		v.networkID = &networkID
+	}
+}
+
+/*
+Verify checks that the seals form an unbroken chain:
+  - root round of each seal is one greater than the root round of the previous seal;
+  - PreviousHash of each seal is the Hash of the previous seal;
+  - timestamps do not decrease;
+  - all seals are of the same network;
+  - epoch either stays the same or is incremented by one.
+
+The "opts" are passed to the UnicitySeal.Verify when the signatures are verified
+(see WithSealTrustBase). In case of inconsistency *SealChainError is returned.
+*/
+func (v *SealChainVerifier) Verify(seals []*UnicitySeal, opts ...VerifyOption) error {
+	var prev *UnicitySeal
+	for i, seal := range seals {
+		if err := v.verifyNext(prev, seal, opts); err != nil {
+			return &SealChainError{Index: i, Prev: prev, Seal: seal, Err: err}
+		}
+		prev = seal
+	}
+	return nil
+}
+
+/*
+VerifyUCs checks that the seals of the UCs form an unbroken chain, see Verify.
+UCs must be sorted by the root round, consecutive UCs sharing the same seal
+(ie UCs of different shards certified in the same root round) are allowed.
+The Index of the returned *SealChainError is the index of the UC.
+*/
+func (v *SealChainVerifier) VerifyUCs(ucs []*UnicityCertificate, opts ...VerifyOption) error {
+	var prev *UnicitySeal
+	for i, uc := range ucs {
+		if uc == nil {
+			return &SealChainError{Index: i, Prev: prev, Err: ErrUnicityCertificateIsNil}
+		}
+		seal := uc.UnicitySeal
+		if prev != nil && seal != nil && seal.RootChainRoundNumber == prev.RootChainRoundNumber && bytes.Equal(seal.Hash, prev.Hash) {
+			continue
+		}
+		if err := v.verifyNext(prev, seal, opts); err != nil {
+			return &SealChainError{Index: i, Prev: prev, Seal: seal, Err: err}
+		}
+		prev = seal
+	}
+	return nil
+}
+
+func (v *SealChainVerifier) verifyNext(prev, seal *UnicitySeal, opts []VerifyOption) error {
+	if seal == nil {
+		return ErrUnicitySealIsNil
+	}
+	if err := seal.IsValid(); err != nil {
+		return fmt.Errorf("invalid unicity seal: %w", err)
+	}
+	if prev == nil {
+		if v.networkID != nil && seal.NetworkID != *v.networkID {
+			return fmt.Errorf("%w: expected network %d, got %d", ErrSealChainNetwork, *v.networkID, seal.NetworkID)
+		}
+		return v.verifySignatures(seal, false, opts)
+	}
+
+	if seal.NetworkID != prev.NetworkID {
+		return fmt.Errorf("%w: expected network %d, got %d", ErrSealChainNetwork, prev.NetworkID, seal.NetworkID)
+	}
+	switch round, prevRound := seal.RootChainRoundNumber, prev.RootChainRoundNumber; {
+	case round == prevRound:
+		return fmt.Errorf("%w: two different seals for the root round %d", ErrSealChainFork, round)
+	case round < prevRound:
+		return fmt.Errorf("%w: root round %d follows root round %d", ErrSealChainRegression, round, prevRound)
+	case round > prevRound+1:
+		return fmt.Errorf("%w: root rounds %d..%d are missing", ErrSealChainGap, prevRound+1, round-1)
+	}
+	if !bytes.Equal(seal.PreviousHash, prev.Hash) {
+		return fmt.Errorf("%w: previous hash %X does not match the hash %X of the root round %d", ErrSealChainFork, seal.PreviousHash, prev.Hash, prev.RootChainRoundNumber)
+	}
+	if seal.Timestamp < prev.Timestamp {
+		return fmt.Errorf("%w: timestamp %d is before the previous timestamp %d", ErrSealChainTimestamp, seal.Timestamp, prev.Timestamp)
+	}
+	switch seal.Epoch {
+	case prev.Epoch:
+		return v.verifySignatures(seal, false, opts)
+	case prev.Epoch + 1:
+		return v.verifySignatures(seal, true, opts)
+	default:
+		return fmt.Errorf("%w: epoch %d follows epoch %d", ErrSealChainEpoch, seal.Epoch, prev.Epoch)
+	}
+}
+
+/*
+verifySignatures verifies the seal against the trust base of its epoch, when
+"transition" is true the seal must be the first seal of the epoch.
+*/
+func (v *SealChainVerifier) verifySignatures(seal *UnicitySeal, transition bool, opts []VerifyOption) error {
+	if v.trustBase == nil {
+		return nil
+	}
+	tb, err := v.trustBase(seal.Epoch)
+	if err != nil {
+		return fmt.Errorf("acquiring trust base of epoch %d: %w", seal.Epoch, err)
+	}
+	if tbv1, ok := tb.(*RootTrustBaseV1); ok {
+		if seal.RootChainRoundNumber < tbv1.EpochStartRound {
+			return fmt.Errorf("%w: root round %d is before the start round %d of the epoch %d", ErrSealChainEpoch, seal.RootChainRoundNumber, tbv1.EpochStartRound, seal.Epoch)
+		}
+		if transition && seal.RootChainRoundNumber != tbv1.EpochStartRound {
+			return fmt.Errorf("%w: epoch %d starts at root round %d, not at %d", ErrSealChainEpoch, seal.Epoch, tbv1.EpochStartRound, seal.RootChainRoundNumber)
+		}
+	}
+	return seal.Verify(tb, opts...)
+}
@@ -0,0 +1,213 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealChainVerifier_Verify(t *testing.T) {
+	keys := genKeys(2)
+	epoch1, err := NewTrustBaseGenesis(NetworkMainNet, []*NodeInfo{{NodeID: "1", SigKey: keys["1"].publicKey, Stake: 1}})
+	require.NoError(t, err)
+	epoch2, err := NextEpoch(epoch1, []*NodeInfo{{NodeID: "2", SigKey: keys["2"].publicKey, Stake: 1}}, nil, WithEpochStartRound(4))
+	require.NoError(t, err)
+	tbLookup := func(epoch uint64) (RootTrustBase, error) {
+		switch epoch {
+		case 1:
+			return epoch1, nil
+		case 2:
+			return epoch2, nil
+		}
+		return nil, errors.New("unknown epoch")
+	}
+
+	newSeal := func(t *testing.T, prev *UnicitySeal, epoch uint64, signer string) *UnicitySeal {
+		seal := &UnicitySeal{
+			Version:              1,
+			NetworkID:            NetworkMainNet,
+			RootChainRoundNumber: 1,
+			Epoch:                epoch,
+			Timestamp:            GenesisTime,
+			Hash:                 []byte{1},
+		}
+		if prev != nil {
+			seal.RootChainRoundNumber = prev.RootChainRoundNumber + 1
+			seal.Timestamp = prev.Timestamp + 1
+			seal.PreviousHash = prev.Hash
+			seal.Hash = []byte{byte(seal.RootChainRoundNumber)}
+		}
+		require.NoError(t, seal.Sign(signer, keys[signer].signer))
+		return seal
+	}
+	// root rounds 1..3 in epoch 1, rounds 4..5 in epoch 2
+	newChain := func(t *testing.T) []*UnicitySeal {
+		seals := []*UnicitySeal{newSeal(t, nil, 1, "1")}
+		seals = append(seals, newSeal(t, seals[0], 1, "1"))
+		seals = append(seals, newSeal(t, seals[1], 1, "1"))
+		seals = append(seals, newSeal(t, seals[2], 2, "2"))
+		seals = append(seals, newSeal(t, seals[3], 2, "2"))
+		return seals
+	}
+	resign := func(t *testing.T, seal *UnicitySeal) {
+		for id := range seal.Signatures {
+			require.NoError(t, seal.Sign(id, keys[id].signer))
+		}
+	}
+	// requireBreak checks that the chain is broken at given index with given error
+	requireBreak := func(t *testing.T, err error, idx int, kind error) {
+		t.Helper()
+		var chainErr *SealChainError
+		require.ErrorAs(t, err, &chainErr)
+		require.Equal(t, idx, chainErr.Index)
+		if kind != nil {
+			require.ErrorIs(t, err, kind)
+		}
+	}
+
+	t.Run("valid chain", func(t *testing.T) {
+		seals := newChain(t)
+		require.NoError(t, NewSealChainVerifier().Verify(seals))
+		require.NoError(t, NewSealChainVerifier(WithSealTrustBase(tbLookup)).Verify(seals))
+		require.NoError(t, NewSealChainVerifier(WithSealNetworkID(NetworkMainNet)).Verify(seals))
+		// run doesn't have to start from the genesis
+		require.NoError(t, NewSealChainVerifier(WithSealTrustBase(tbLookup)).Verify(seals[2:]))
+		require.NoError(t, NewSealChainVerifier().Verify(nil))
+	})
+
+	t.Run("verify options are applied to the seals", func(t *testing.T) {
+		seals := newChain(t)
+		v := NewSealChainVerifier(WithSealTrustBase(tbLookup))
+		require.NoError(t, v.Verify(seals, WithAcceptedNetworks(NetworkMainNet, NetworkTestNet)))
+		err := v.Verify(seals, WithAcceptedNetworks(NetworkTestNet))
+		requireBreak(t, err, 0, ErrNetworkMismatch)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		seals := newChain(t)
+		err := NewSealChainVerifier().Verify(append(seals[:1:1], seals[3:]...))
+		requireBreak(t, err, 1, ErrSealChainGap)
+		require.EqualError(t, err, "seal chain broken at index 1 (root round 4): gap in the seal chain: root rounds 2..3 are missing")
+	})
+
+	t.Run("fork", func(t *testing.T) {
+		seals := newChain(t)
+		seals[2].PreviousHash = []byte{9}
+		resign(t, seals[2])
+		err := NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 2, ErrSealChainFork)
+		require.EqualError(t, err, "seal chain broken at index 2 (root round 3): fork in the seal chain: previous hash 09 does not match the hash 02 of the root round 2")
+
+		// two seals of the same round
+		seals = newChain(t)
+		fork := *seals[1]
+		fork.Hash = []byte{7}
+		err = NewSealChainVerifier().Verify([]*UnicitySeal{seals[0], seals[1], &fork})
+		requireBreak(t, err, 2, ErrSealChainFork)
+	})
+
+	t.Run("root round regression", func(t *testing.T) {
+		seals := newChain(t)
+		err := NewSealChainVerifier().Verify([]*UnicitySeal{seals[0], seals[2], seals[1]})
+		requireBreak(t, err, 1, ErrSealChainGap)
+		err = NewSealChainVerifier().Verify([]*UnicitySeal{seals[1], seals[0]})
+		requireBreak(t, err, 1, ErrSealChainRegression)
+	})
+
+	t.Run("timestamp regression", func(t *testing.T) {
+		seals := newChain(t)
+		seals[3].Timestamp = seals[2].Timestamp - 1
+		resign(t, seals[3])
+		err := NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 3, ErrSealChainTimestamp)
+	})
+
+	t.Run("network mismatch", func(t *testing.T) {
+		seals := newChain(t)
+		err := NewSealChainVerifier(WithSealNetworkID(NetworkTestNet)).Verify(seals)
+		requireBreak(t, err, 0, ErrSealChainNetwork)
+
+		seals[1].NetworkID = NetworkTestNet
+		resign(t, seals[1])
+		err = NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 1, ErrSealChainNetwork)
+	})
+
+	t.Run("epoch transition", func(t *testing.T) {
+		// epoch skipped
+		seals := newChain(t)
+		seals[3].Epoch = 3
+		resign(t, seals[3])
+		err := NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 3, ErrSealChainEpoch)
+
+		// epoch goes back
+		seals = newChain(t)
+		seals[4].Epoch = 1
+		resign(t, seals[4])
+		err = NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 4, ErrSealChainEpoch)
+
+		// new epoch starts in wrong root round
+		seals = newChain(t)
+		seals[2].Epoch = 2
+		err = NewSealChainVerifier(WithSealTrustBase(tbLookup)).Verify(seals)
+		requireBreak(t, err, 2, ErrSealChainEpoch)
+		require.ErrorContains(t, err, "root round 3 is before the start round 4 of the epoch 2")
+
+		// new epoch starts later than announced in the trust base
+		seals = newChain(t)
+		seals[3] = newSeal(t, seals[2], 1, "1")
+		seals[4] = newSeal(t, seals[3], 2, "2")
+		err = NewSealChainVerifier(WithSealTrustBase(tbLookup)).Verify(seals)
+		requireBreak(t, err, 4, ErrSealChainEpoch)
+		require.ErrorContains(t, err, "epoch 2 starts at root round 4, not at 5")
+
+		// seal signed by the validators of the wrong epoch
+		seals = newChain(t)
+		seals[4].Epoch = 1
+		seals[3].Epoch = 1
+		resign(t, seals[3])
+		resign(t, seals[4])
+		require.NoError(t, NewSealChainVerifier().Verify(seals))
+		err = NewSealChainVerifier(WithSealTrustBase(tbLookup)).Verify(seals)
+		requireBreak(t, err, 3, nil)
+		require.ErrorContains(t, err, "verifying signatures: quorum not reached")
+
+		// unknown epoch
+		err = NewSealChainVerifier(WithSealTrustBase(func(uint64) (RootTrustBase, error) { return nil, errors.New("unknown") })).Verify(seals)
+		requireBreak(t, err, 0, nil)
+		require.EqualError(t, err, "seal chain broken at index 0 (root round 1): acquiring trust base of epoch 1: unknown")
+	})
+
+	t.Run("invalid seal", func(t *testing.T) {
+		seals := newChain(t)
+		seals[1] = nil
+		err := NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 1, ErrUnicitySealIsNil)
+		require.EqualError(t, err, "seal chain broken at index 1: unicity seal is nil")
+
+		seals = newChain(t)
+		seals[2].Signatures = nil
+		err = NewSealChainVerifier().Verify(seals)
+		requireBreak(t, err, 2, ErrUnicitySealSignatureIsNil)
+	})
+
+	t.Run("UCs", func(t *testing.T) {
+		seals := newChain(t)
+		newUC := func(seal *UnicitySeal) *UnicityCertificate {
+			return &UnicityCertificate{Version: 1, UnicitySeal: seal}
+		}
+		// UCs of multiple shards from the same root round
+		ucs := []*UnicityCertificate{newUC(seals[0]), newUC(seals[0]), newUC(seals[1]), newUC(seals[2]), newUC(seals[2])}
+		require.NoError(t, NewSealChainVerifier(WithSealTrustBase(tbLookup)).VerifyUCs(ucs))
+
+		ucs = []*UnicityCertificate{newUC(seals[0]), newUC(seals[0]), newUC(seals[2])}
+		err := NewSealChainVerifier().VerifyUCs(ucs)
+		requireBreak(t, err, 2, ErrSealChainGap)
+
+		err = NewSealChainVerifier().VerifyUCs([]*UnicityCertificate{newUC(seals[0]), nil})
+		requireBreak(t, err, 1, ErrUnicityCertificateIsNil)
+	})
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+
+	abhash "github.com/alphabill-org/alphabill-go-base/hash"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var ErrTechnicalRecordIsNil = errors.New("technical record is nil")
+
+/*
+TechnicalRecord is created by the root chain when certifying a shard round,
+it describes the expectations for the next round of the shard. Hash of the
+technical record is certified in the UnicityCertificate.TRHash.
+*/
+type TechnicalRecord struct {
+	_        struct{}  `cbor:",toarray"`
+	Version  ABVersion `json:"version"`
+	Round    uint64    `json:"round"`    // number of the next round of the shard
+	Epoch    uint64    `json:"epoch"`    // epoch of the next round of the shard
+	Leader   string    `json:"leader"`   // ID of the validator expected to propose the next block
+	StatHash hex.Bytes `json:"statHash"` // hash of the statistical records of the validators
+	FeeHash  hex.Bytes `json:"feeHash"`  // hash of the fee records of the validators
+}
+
+func (tr *TechnicalRecord) IsValid() error {
+	if tr == nil {
+		return ErrTechnicalRecordIsNil
+	}
+	if tr.Version != 1 {
+		return ErrInvalidVersion(tr)
+	}
+	if tr.Round == 0 {
+		return errors.New("round number is zero")
+	}
+	if tr.Leader == "" {
+		return errors.New("leader is unassigned")
+	}
+	return nil
+}
+
+// Hash returns the hash of the technical record as used in the UC.TRHash.
+func (tr *TechnicalRecord) Hash(algorithm crypto.Hash) ([]byte, error) {
+	return abhash.HashValues(algorithm, tr)
+}
+
+/*
+Verify checks that the technical record is valid and is the record certified
+by the UC, ie that the hash of the technical record equals UC.TRHash and that
+the record is for the round following the round certified by the UC.
+*/
+func (tr *TechnicalRecord) Verify(uc *UnicityCertificate, algorithm crypto.Hash) error {
+	if err := tr.IsValid(); err != nil {
+		return fmt.Errorf("invalid technical record: %w", err)
+	}
+	if uc == nil {
+		return ErrUnicityCertificateIsNil
+	}
+	if uc.InputRecord == nil {
+		return ErrInputRecordIsNil
+	}
+	h, err := tr.Hash(algorithm)
+	if err != nil {
+		return fmt.Errorf("hashing technical record: %w", err)
+	}
+	if !bytes.Equal(h, uc.TRHash) {
+		return fmt.Errorf("technical record hash %X does not match UC.TRHash %X", h, uc.TRHash)
+	}
+	if next := uc.InputRecord.RoundNumber + 1; tr.Round != next {
+		return fmt.Errorf("technical record is for round %d, expected round %d", tr.Round, next)
+	}
+	return nil
+}
+
+/*
+IsLeader returns true when the "nodeID" is expected to propose the next block.
+*/
+func (tr *TechnicalRecord) IsLeader(nodeID string) bool {
+	return tr != nil && tr.Leader == nodeID
+}
+
+/*
+CheckInputRecord checks that the "ir" (proposed by the shard for certification)
+matches the next round expectations of the technical record.
+*/
+func (tr *TechnicalRecord) CheckInputRecord(ir *InputRecord) error {
+	if tr == nil {
+		return ErrTechnicalRecordIsNil
+	}
+	if ir == nil {
+		return ErrInputRecordIsNil
+	}
+	if ir.RoundNumber != tr.Round {
+		return fmt.Errorf("expected round %d, got %d", tr.Round, ir.RoundNumber)
+	}
+	if ir.Epoch != tr.Epoch {
+		return fmt.Errorf("expected epoch %d, got %d", tr.Epoch, ir.Epoch)
+	}
+	return nil
+}
+
+/*
+CheckProposal checks that the block proposal for the next round is made by
+the expected leader and certifies expected round.
+*/
+func (tr *TechnicalRecord) CheckProposal(proposerID string, ir *InputRecord) error {
+	if !tr.IsLeader(proposerID) {
+		if tr == nil {
+			return ErrTechnicalRecordIsNil
+		}
+		return fmt.Errorf("expected leader %q, got %q", tr.Leader, proposerID)
+	}
+	return tr.CheckInputRecord(ir)
+}
+
+func (tr *TechnicalRecord) GetVersion() ABVersion {
+	if tr != nil && tr.Version > 0 {
+		return tr.Version
+	}
+	return 1
+}
+
+func (tr *TechnicalRecord) MarshalCBOR() ([]byte, error) {
+	type alias TechnicalRecord
+	if tr.Version == 0 {
+		tr.Version = tr.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(TechnicalRecordTag, (*alias)(tr))
+}
+
+func (tr *TechnicalRecord) UnmarshalCBOR(data []byte) error {
+	type alias TechnicalRecord
+	if err := Cbor.UnmarshalTaggedValue(TechnicalRecordTag, data, (*alias)(tr)); err != nil {
+		return err
+	}
+	return EnsureVersion(tr, tr.Version, 1)
+}
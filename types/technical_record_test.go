@@ -0,0 +1,132 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestTechnicalRecord(t *testing.T) {
+	validTR := func() *TechnicalRecord {
+		return &TechnicalRecord{
+			Version:  1,
+			Round:    6,
+			Epoch:    1,
+			Leader:   "node1",
+			StatHash: []byte{1, 2, 3},
+			FeeHash:  []byte{4, 5, 6},
+		}
+	}
+
+	t.Run("IsValid", func(t *testing.T) {
+		require.NoError(t, validTR().IsValid())
+
+		var tr *TechnicalRecord
+		require.ErrorIs(t, tr.IsValid(), ErrTechnicalRecordIsNil)
+
+		tr = validTR()
+		tr.Version = 0
+		require.ErrorContains(t, tr.IsValid(), "invalid version")
+
+		tr = validTR()
+		tr.Round = 0
+		require.EqualError(t, tr.IsValid(), "round number is zero")
+
+		tr = validTR()
+		tr.Leader = ""
+		require.EqualError(t, tr.IsValid(), "leader is unassigned")
+	})
+
+	t.Run("hash is deterministic", func(t *testing.T) {
+		h1, err := validTR().Hash(crypto.SHA256)
+		require.NoError(t, err)
+		require.Len(t, h1, 32)
+		h2, err := validTR().Hash(crypto.SHA256)
+		require.NoError(t, err)
+		require.Equal(t, h1, h2)
+
+		tr := validTR()
+		tr.Leader = "node2"
+		h3, err := tr.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		require.NotEqual(t, h1, h3)
+	})
+
+	t.Run("verify against UC", func(t *testing.T) {
+		signer, _ := testsig.CreateSignerAndVerifier(t)
+		pdr := &PartitionDescriptionRecord{
+			Version:     1,
+			NetworkID:   NetworkMainNet,
+			PartitionID: 0x01020304,
+			TypeIDLen:   8,
+			UnitIDLen:   256,
+			T2Timeout:   2500 * time.Millisecond,
+		}
+		ir := &InputRecord{
+			Version:      1,
+			RoundNumber:  5,
+			Epoch:        1,
+			PreviousHash: []byte{0, 0, 1},
+			Hash:         []byte{0, 0, 2},
+			BlockHash:    []byte{0, 0, 3},
+			SummaryValue: []byte{0, 0, 4},
+			Timestamp:    NewTimestamp(),
+		}
+		tr := validTR()
+		trHash, err := tr.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		uc := createUnicityCertificate(t, "test", signer, ir, trHash, pdr)
+		require.NoError(t, tr.Verify(uc, crypto.SHA256))
+
+		other := validTR()
+		other.FeeHash = []byte{7}
+		require.ErrorContains(t, other.Verify(uc, crypto.SHA256), "technical record hash")
+
+		// TR certified with wrong round
+		other = validTR()
+		other.Round = 5
+		otherHash, err := other.Hash(crypto.SHA256)
+		require.NoError(t, err)
+		uc = createUnicityCertificate(t, "test", signer, ir, otherHash, pdr)
+		require.EqualError(t, other.Verify(uc, crypto.SHA256), "technical record is for round 5, expected round 6")
+
+		require.ErrorIs(t, tr.Verify(nil, crypto.SHA256), ErrUnicityCertificateIsNil)
+		require.ErrorIs(t, tr.Verify(&UnicityCertificate{}, crypto.SHA256), ErrInputRecordIsNil)
+		require.ErrorIs(t, (*TechnicalRecord)(nil).Verify(uc, crypto.SHA256), ErrTechnicalRecordIsNil)
+	})
+
+	t.Run("next round expectations", func(t *testing.T) {
+		tr := validTR()
+		require.True(t, tr.IsLeader("node1"))
+		require.False(t, tr.IsLeader("node2"))
+		require.False(t, (*TechnicalRecord)(nil).IsLeader(""))
+
+		ir := &InputRecord{Version: 1, RoundNumber: 6, Epoch: 1}
+		require.NoError(t, tr.CheckInputRecord(ir))
+		require.NoError(t, tr.CheckProposal("node1", ir))
+		require.EqualError(t, tr.CheckProposal("node2", ir), `expected leader "node1", got "node2"`)
+		require.ErrorIs(t, (*TechnicalRecord)(nil).CheckProposal("node1", ir), ErrTechnicalRecordIsNil)
+
+		require.ErrorIs(t, tr.CheckInputRecord(nil), ErrInputRecordIsNil)
+		require.EqualError(t, tr.CheckInputRecord(&InputRecord{Version: 1, RoundNumber: 7, Epoch: 1}), "expected round 6, got 7")
+		require.EqualError(t, tr.CheckInputRecord(&InputRecord{Version: 1, RoundNumber: 6, Epoch: 2}), "expected epoch 1, got 2")
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		tr := validTR()
+		data, err := tr.MarshalCBOR()
+		require.NoError(t, err)
+		tr2 := &TechnicalRecord{}
+		require.NoError(t, Cbor.Unmarshal(data, tr2))
+		require.Equal(t, tr, tr2)
+
+		tr.Version = 2
+		data, err = tr.MarshalCBOR()
+		require.NoError(t, err)
+		require.EqualError(t, Cbor.Unmarshal(data, tr2), "invalid version (type *types.TechnicalRecord), expected 1, got 2")
+	})
+}
@@ -0,0 +1,197 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+/*
+TxErrorCode identifies the reason of the transaction failure.
+
+Codes below TxErrCodeTxSystemBase are common to all transaction systems, codes
+starting from TxErrCodeTxSystemBase are specific to the transaction system
+(partition type) which executed the transaction, see RegisterTxErrorCodes.
+Codes are part of the transaction record and thus must never be reassigned.
+*/
+type TxErrorCode uint32
+
+const (
+	TxErrCodeUnknown           TxErrorCode = 0
+	TxErrCodeOutOfGas          TxErrorCode = 1
+	TxErrCodeInvalidCounter    TxErrorCode = 2
+	TxErrCodeInsufficientFee   TxErrorCode = 3
+	TxErrCodePredicateFailure  TxErrorCode = 4
+	TxErrCodeUnitNotFound      TxErrorCode = 5
+	TxErrCodeInvalidAttributes TxErrorCode = 6
+
+	// TxErrCodeTxSystemBase is the first code available for the transaction system specific codes.
+	TxErrCodeTxSystemBase TxErrorCode = 1000
+)
+
+/*
+TxError is the serializable description of the transaction failure, stored into
+the ServerMetadata.ProcessingDetails of the failed transaction by the
+ServerMetadata.SetError.
+
+Transaction system reports the reason of the failure by returning (possibly
+wrapped) TxError created with NewTxError, other errors are recorded with code
+TxErrCodeUnknown.
+
+The processing details are part of the hashed transaction record so only the
+Code, UnitID and the fixed Message (name of the code) of the TxError are
+recorded, the text of the wrapped errors is not. This way all the
+implementations of the transaction system record the same details.
+*/
+type TxError struct {
+	_       struct{}    `cbor:",toarray"`
+	Version ABVersion   `json:"version"`
+	Code    TxErrorCode `json:"code"`
+	Message string      `json:"message"`          // fixed description of the failure, may be empty
+	UnitID  UnitID      `json:"unitId,omitempty"` // unit which caused the failure, may be nil
+	err     error
+}
+
+/*
+NewTxError returns TxError with given code, the message is the name of the code
+(empty for the transaction system specific codes). The "unitID" and "err" are
+optional, the "err" is the cause of the failure returned by Unwrap, it is not
+recorded in the transaction record.
+*/
+func NewTxError(code TxErrorCode, unitID UnitID, err error) *TxError {
+	return &TxError{
+		Version: 1,
+		Code:    code,
+		Message: TxErrorCodeName(0, code),
+		UnitID:  unitID,
+		err:     err,
+	}
+}
+
+func (e *TxError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = fmt.Sprintf("transaction error %d", e.Code)
+	}
+	if e.err != nil {
+		return msg + ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *TxError) Unwrap() error {
+	return e.err
+}
+
+/*
+txErrorOf returns TxError describing "err". When "err" wraps TxError its code
+and unit ID are used, for other errors only the code is set. The message is
+always the fixed name of the code so that the record doesn't depend on the
+wording of the error.
+*/
+func txErrorOf(err error) *TxError {
+	detail := &TxError{Version: 1, Code: TxErrCodeUnknown}
+	if txErr := (*TxError)(nil); errors.As(err, &txErr) {
+		detail.Code = txErr.Code
+		detail.UnitID = txErr.UnitID
+		detail.Message = TxErrorCodeName(0, txErr.Code)
+	} else if errors.Is(err, ErrOutOfGas) {
+		detail.Code = TxErrCodeOutOfGas
+	}
+	return detail
+}
+
+func (e *TxError) IsValid() error {
+	if e == nil {
+		return errors.New("transaction error is nil")
+	}
+	if e.Version != 1 {
+		return ErrInvalidVersion(e)
+	}
+	return nil
+}
+
+func (e *TxError) GetVersion() ABVersion {
+	if e != nil && e.Version > 0 {
+		return e.Version
+	}
+	return 1
+}
+
+func (e *TxError) MarshalCBOR() ([]byte, error) {
+	type alias TxError
+	if e.Version == 0 {
+		e.Version = e.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(TxErrorTag, (*alias)(e))
+}
+
+func (e *TxError) UnmarshalCBOR(data []byte) error {
+	type alias TxError
+	if err := Cbor.UnmarshalTaggedValue(TxErrorTag, data, (*alias)(e)); err != nil {
+		return err
+	}
+	return EnsureVersion(e, e.Version, 1)
+}
+
+var txErrorCodes = struct {
+	sync.RWMutex
+	names map[PartitionTypeID]map[TxErrorCode]string
+}{
+	names: map[PartitionTypeID]map[TxErrorCode]string{
+		0: {
+			TxErrCodeUnknown:           "unknown",
+			TxErrCodeOutOfGas:          "out of gas",
+			TxErrCodeInvalidCounter:    "invalid counter",
+			TxErrCodeInsufficientFee:   "insufficient fee",
+			TxErrCodePredicateFailure:  "predicate failure",
+			TxErrCodeUnitNotFound:      "unit not found",
+			TxErrCodeInvalidAttributes: "invalid attributes",
+		},
+	},
+}
+
+/*
+RegisterTxErrorCodes registers names of the transaction system specific error
+codes of the partition type. Codes must be at least TxErrCodeTxSystemBase and
+code which is already registered for the partition type can't be registered
+again.
+*/
+func RegisterTxErrorCodes(partitionType PartitionTypeID, codes map[TxErrorCode]string) error {
+	if partitionType == 0 {
+		return errors.New("partition type ID must be assigned")
+	}
+	txErrorCodes.Lock()
+	defer txErrorCodes.Unlock()
+
+	names := txErrorCodes.names[partitionType]
+	for code := range codes {
+		if code < TxErrCodeTxSystemBase {
+			return fmt.Errorf("code %d is reserved for common errors, transaction system codes must be at least %d", code, TxErrCodeTxSystemBase)
+		}
+		if _, ok := names[code]; ok {
+			return fmt.Errorf("code %d is already registered for partition type %d", code, partitionType)
+		}
+	}
+	if names == nil {
+		names = make(map[TxErrorCode]string, len(codes))
+		txErrorCodes.names[partitionType] = names
+	}
+	for code, name := range codes {
+		names[code] = name
+	}
+	return nil
+}
+
+/*
+TxErrorCodeName returns the name of the error code in the context of the transaction
+system of the partition type. Empty string is returned for unregistered codes.
+*/
+func TxErrorCodeName(partitionType PartitionTypeID, code TxErrorCode) string {
+	if code < TxErrCodeTxSystemBase {
+		partitionType = 0
+	}
+	txErrorCodes.RLock()
+	defer txErrorCodes.RUnlock()
+	return txErrorCodes.names[partitionType][code]
+}
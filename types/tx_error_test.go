@@ -0,0 +1,142 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerMetadata_SetError(t *testing.T) {
+	t.Run("TxError", func(t *testing.T) {
+		cause := errors.New("expected counter 5, got 4")
+		sm := &ServerMetadata{SuccessIndicator: TxStatusSuccessful, TargetUnits: []UnitID{{1}}}
+		require.NoError(t, sm.SetError(fmt.Errorf("executing transfer: %w", NewTxError(TxErrCodeInvalidCounter, UnitID{1, 2}, cause))))
+		require.Equal(t, TxStatusFailed, sm.SuccessIndicator)
+		require.Empty(t, sm.TargetUnits)
+		require.ErrorIs(t, sm.ErrDetail(), cause)
+		require.EqualError(t, sm.ErrDetail(), "executing transfer: invalid counter: expected counter 5, got 4")
+
+		txErr, err := sm.TxError()
+		require.NoError(t, err)
+		require.Equal(t, &TxError{Version: 1, Code: TxErrCodeInvalidCounter, Message: "invalid counter", UnitID: UnitID{1, 2}}, txErr)
+		require.Equal(t, "invalid counter", TxErrorCodeName(0, txErr.Code))
+
+		// the details survive serialization of the record
+		txr := &TransactionRecord{Version: 1, TransactionOrder: []byte{0x80}, ServerMetadata: sm}
+		data, err := txr.MarshalCBOR()
+		require.NoError(t, err)
+		txr2 := &TransactionRecord{}
+		require.NoError(t, txr2.UnmarshalCBOR(data))
+		txErr2, err := txr2.TxError()
+		require.NoError(t, err)
+		require.Equal(t, txErr, txErr2)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		sm := &ServerMetadata{}
+		require.NoError(t, sm.SetError(fmt.Errorf("fee handling: %w", ErrOutOfGas)))
+		require.Equal(t, TxErrOutOfGas, sm.SuccessIndicator)
+		txErr, err := sm.TxError()
+		require.NoError(t, err)
+		require.Equal(t, &TxError{Version: 1, Code: TxErrCodeOutOfGas}, txErr)
+
+		require.NoError(t, sm.SetError(errors.New("boom")))
+		txErr, err = sm.TxError()
+		require.NoError(t, err)
+		require.Equal(t, &TxError{Version: 1, Code: TxErrCodeUnknown}, txErr)
+
+		require.NoError(t, sm.SetError(nil))
+		require.Nil(t, sm.ProcessingDetails)
+		txErr, err = sm.TxError()
+		require.NoError(t, err)
+		require.Nil(t, txErr)
+	})
+
+	t.Run("custom message is not recorded", func(t *testing.T) {
+		sm := &ServerMetadata{}
+		txErr := NewTxError(TxErrCodeUnitNotFound, nil, nil)
+		txErr.Message = "unit 0x01 not found"
+		require.NoError(t, sm.SetError(txErr))
+		txErr, err := sm.TxError()
+		require.NoError(t, err)
+		require.Equal(t, "unit not found", txErr.Message)
+
+		require.EqualError(t, (*ServerMetadata)(nil).SetError(txErr), "server metadata is nil")
+	})
+
+	t.Run("no details", func(t *testing.T) {
+		txErr, err := (&ServerMetadata{SuccessIndicator: TxStatusFailed}).TxError()
+		require.NoError(t, err)
+		require.Nil(t, txErr)
+
+		details, err := Cbor.Marshal(testProcessingDetails{A: 1})
+		require.NoError(t, err)
+		txErr, err = (&ServerMetadata{SuccessIndicator: TxStatusSuccessful, ProcessingDetails: details}).TxError()
+		require.NoError(t, err)
+		require.Nil(t, txErr)
+
+		_, err = (&ServerMetadata{SuccessIndicator: TxStatusFailed, ProcessingDetails: details}).TxError()
+		require.ErrorContains(t, err, "decoding transaction error")
+
+		_, err = (*ServerMetadata)(nil).TxError()
+		require.EqualError(t, err, "server metadata is nil")
+		_, err = (*TransactionRecord)(nil).TxError()
+		require.ErrorIs(t, err, ErrTransactionRecordIsNil)
+	})
+}
+
+func TestTxError_CBOR(t *testing.T) {
+	txErr := NewTxError(TxErrCodePredicateFailure, nil, errors.New("owner proof is invalid"))
+	data, err := Cbor.Marshal(txErr)
+	require.NoError(t, err)
+	txErr2 := &TxError{}
+	require.NoError(t, Cbor.Unmarshal(data, txErr2))
+	require.NoError(t, txErr2.IsValid())
+	require.Equal(t, TxErrCodePredicateFailure, txErr2.Code)
+	require.Equal(t, "predicate failure", txErr2.Error())
+	require.Nil(t, txErr2.Unwrap())
+	require.Equal(t, "predicate failure: owner proof is invalid", txErr.Error())
+
+	txErr.Version = 2
+	data, err = Cbor.Marshal(txErr)
+	require.NoError(t, err)
+	require.ErrorContains(t, Cbor.Unmarshal(data, &TxError{}), "invalid version (type *types.TxError), expected 1, got 2")
+	require.ErrorContains(t, txErr.IsValid(), "invalid version")
+}
+
+func TestNewTxError(t *testing.T) {
+	// the cause is optional
+	txErr := NewTxError(TxErrCodeUnitNotFound, UnitID{1}, nil)
+	require.Equal(t, "unit not found", txErr.Error())
+	require.Nil(t, txErr.Unwrap())
+
+	// transaction system specific codes have no fixed message
+	txErr = NewTxError(TxErrCodeTxSystemBase+1, nil, nil)
+	require.Empty(t, txErr.Message)
+	require.Equal(t, "transaction error 1001", txErr.Error())
+
+	// different causes of the same failure are recorded the same way
+	sm1, sm2 := &ServerMetadata{}, &ServerMetadata{}
+	require.NoError(t, sm1.SetError(fmt.Errorf("v1: %w", NewTxError(TxErrCodeInvalidCounter, UnitID{1}, errors.New("expected 1")))))
+	require.NoError(t, sm2.SetError(fmt.Errorf("node v2 says: %w", NewTxError(TxErrCodeInvalidCounter, UnitID{1}, errors.New("counter mismatch")))))
+	require.Equal(t, sm1.ProcessingDetails, sm2.ProcessingDetails)
+}
+
+func TestRegisterTxErrorCodes(t *testing.T) {
+	const partitionType PartitionTypeID = 0xFFFF0001
+	require.EqualError(t, RegisterTxErrorCodes(0, map[TxErrorCode]string{TxErrCodeTxSystemBase: "foo"}), "partition type ID must be assigned")
+	require.EqualError(t, RegisterTxErrorCodes(partitionType, map[TxErrorCode]string{TxErrCodeInvalidCounter: "foo"}),
+		"code 2 is reserved for common errors, transaction system codes must be at least 1000")
+
+	require.NoError(t, RegisterTxErrorCodes(partitionType, map[TxErrorCode]string{TxErrCodeTxSystemBase: "bill locked"}))
+	require.EqualError(t, RegisterTxErrorCodes(partitionType, map[TxErrorCode]string{TxErrCodeTxSystemBase: "foo"}),
+		"code 1000 is already registered for partition type 4294901761")
+
+	require.Equal(t, "bill locked", TxErrorCodeName(partitionType, TxErrCodeTxSystemBase))
+	require.Empty(t, TxErrorCodeName(partitionType+1, TxErrCodeTxSystemBase))
+	require.Empty(t, TxErrorCodeName(partitionType, TxErrCodeTxSystemBase+1))
+	// common codes are the same for all partition types
+	require.Equal(t, "insufficient fee", TxErrorCodeName(partitionType, TxErrCodeInsufficientFee))
+}
@@ -0,0 +1,200 @@
+package types
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var ErrTxMultiProofIsNil = errors.New("transaction multi-proof is nil")
+
+/*
+TxMultiProof proves inclusion of several transaction records of the same block.
+Compared to the individual TxRecordProofs the UC and the block header hash are
+included only once and the Merkle tree hashes shared by the records are not
+repeated (see mt.MerkleTree.GetMultiPath).
+*/
+type TxMultiProof struct {
+	_                  struct{}             `cbor:",toarray"`
+	Version            ABVersion            `json:"version"`
+	TxRecords          []*TransactionRecord `json:"txRecords"` // records in the order of TxIndexes
+	TxIndexes          []uint32             `json:"txIndexes"` // indexes of the records in the block, in ascending order
+	TxCount            uint32               `json:"txCount"`   // number of transactions in the block
+	BlockHeaderHash    hex.Bytes            `json:"blockHeaderHash"`
+	Siblings           []hex.Bytes          `json:"siblings"` // hashes of the sub-trees not containing any of the records
+	UnicityCertificate TaggedCBOR           `json:"unicityCertificate"`
+}
+
+/*
+NewTxMultiProof returns inclusion proof of the transactions of the block with
+given indexes, indexes may be in any order but must not repeat.
+*/
+func NewTxMultiProof(block *Block, algorithm crypto.Hash, txIndexes ...int) (*TxMultiProof, error) {
+	if block == nil {
+		return nil, ErrBlockIsNil
+	}
+	if len(txIndexes) == 0 {
+		return nil, errors.New("no transaction indexes")
+	}
+	if len(block.Transactions) > math.MaxUint32 {
+		return nil, fmt.Errorf("block has too many transactions: %d", len(block.Transactions))
+	}
+	idx := slices.Clone(txIndexes)
+	slices.Sort(idx)
+	for i, v := range idx {
+		if v < 0 || v > len(block.Transactions)-1 {
+			return nil, fmt.Errorf("invalid tx index: %d", v)
+		}
+		if i > 0 && v == idx[i-1] {
+			return nil, fmt.Errorf("duplicate tx index: %d", v)
+		}
+	}
+
+	tree, err := mt.New(algorithm, block.Transactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merkle tree: %w", err)
+	}
+	siblings, err := tree.GetMultiPath(idx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract merkle multi-path: %w", err)
+	}
+	headerHash, err := block.HeaderHash(algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate block header hash: %w", err)
+	}
+
+	proof := &TxMultiProof{
+		Version:            1,
+		TxRecords:          make([]*TransactionRecord, len(idx)),
+		TxIndexes:          make([]uint32, len(idx)),
+		TxCount:            uint32(len(block.Transactions)),
+		BlockHeaderHash:    headerHash,
+		Siblings:           make([]hex.Bytes, len(siblings)),
+		UnicityCertificate: block.UnicityCertificate,
+	}
+	for i, v := range idx {
+		proof.TxRecords[i] = block.Transactions[v]
+		proof.TxIndexes[i] = uint32(v)
+	}
+	for i, h := range siblings {
+		proof.Siblings[i] = h
+	}
+	return proof, nil
+}
+
+/*
+VerifyTxMultiInclusion checks that all the transactions of the multi-proof are
+included in the block certified by the UC of the proof. The checks are the same
+as done by VerifyTxInclusion for each transaction.
+*/
+func VerifyTxMultiInclusion(proof *TxMultiProof, tb RootTrustBase, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := proof.IsValid(); err != nil {
+		return err
+	}
+	uc, err := proof.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate: %w", err)
+	}
+	// all the transactions share the UC so it is verified only once (together
+	// with the first transaction), the other transactions are checked the same
+	// way the transactions of the verified UC are checked by VerifyTxInclusion
+	txos := make([]*TransactionOrder, len(proof.TxRecords))
+	for i, txr := range proof.TxRecords {
+		if txos[i], err = txr.GetTransactionOrderV1(); err != nil {
+			return fmt.Errorf("failed to get transaction order %d: %w", i, err)
+		}
+	}
+	if err := verifyTxCertificate(uc, txos[0], tb, nil, hashAlgorithm, opts...); err != nil {
+		return err
+	}
+	for i := 1; i < len(txos); i++ {
+		if err := checkTxOfCertificate(uc, txos[i]); err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+
+	idx := make([]int, len(proof.TxIndexes))
+	for i, v := range proof.TxIndexes {
+		idx[i] = int(v)
+	}
+	siblings := make([][]byte, len(proof.Siblings))
+	for i, h := range proof.Siblings {
+		siblings[i] = h
+	}
+	rootHash, err := mt.EvalMultiPath(int(proof.TxCount), idx, proof.TxRecords, siblings, hashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate merkle multi-path: %w", err)
+	}
+	return verifyProofBlockHash(uc, proof.BlockHeaderHash, rootHash, hashAlgorithm)
+}
+
+func (p *TxMultiProof) IsValid() error {
+	if p == nil {
+		return ErrTxMultiProofIsNil
+	}
+	if p.Version != 1 {
+		return ErrInvalidVersion(p)
+	}
+	if len(p.TxRecords) == 0 {
+		return errors.New("no transaction records")
+	}
+	if len(p.TxRecords) != len(p.TxIndexes) {
+		return fmt.Errorf("proof has %d transaction records but %d indexes", len(p.TxRecords), len(p.TxIndexes))
+	}
+	for i, txr := range p.TxRecords {
+		if err := txr.IsValid(); err != nil {
+			return fmt.Errorf("transaction record %d: %w", i, err)
+		}
+	}
+	for i, v := range p.TxIndexes {
+		if v >= p.TxCount {
+			return fmt.Errorf("tx index %d is out of range, block has %d transactions", v, p.TxCount)
+		}
+		if i > 0 && v <= p.TxIndexes[i-1] {
+			return fmt.Errorf("tx indexes must be in ascending order without duplicates, got %d after %d", v, p.TxIndexes[i-1])
+		}
+	}
+	return nil
+}
+
+func (p *TxMultiProof) GetUC() (*UnicityCertificate, error) {
+	if p == nil {
+		return nil, ErrTxMultiProofIsNil
+	}
+	if p.UnicityCertificate == nil {
+		return nil, ErrUnicityCertificateIsNil
+	}
+	uc := &UnicityCertificate{}
+	if err := Cbor.Unmarshal(p.UnicityCertificate, uc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal unicity certificate: %w", err)
+	}
+	return uc, nil
+}
+
+func (p *TxMultiProof) GetVersion() ABVersion {
+	if p != nil && p.Version > 0 {
+		return p.Version
+	}
+	return 1
+}
+
+func (p *TxMultiProof) MarshalCBOR() ([]byte, error) {
+	type alias TxMultiProof
+	if p.Version == 0 {
+		p.Version = p.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(TxMultiProofTag, (*alias)(p))
+}
+
+func (p *TxMultiProof) UnmarshalCBOR(data []byte) error {
+	type alias TxMultiProof
+	if err := Cbor.UnmarshalTaggedValue(TxMultiProofTag, data, (*alias)(p)); err != nil {
+		return err
+	}
+	return EnsureVersion(p, p.Version, 1)
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+func TestTxMultiProof(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	txs := make([]*TransactionRecord, 6)
+	for i := range txs {
+		txs[i] = createTransactionRecord(t, createTransactionOrder(t), uint64(i+1))
+	}
+	block := createBlock(t, "test", signer, txs...)
+
+	t.Run("verify", func(t *testing.T) {
+		proof, err := NewTxMultiProof(block, crypto.SHA256, 4, 0, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint32{0, 1, 4}, proof.TxIndexes)
+		require.Equal(t, []*TransactionRecord{block.Transactions[0], block.Transactions[1], block.Transactions[4]}, proof.TxRecords)
+		require.EqualValues(t, 6, proof.TxCount)
+		require.NoError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256))
+
+		// the members verify individually too
+		for _, idx := range proof.TxIndexes {
+			p, err := NewTxRecordProof(block, int(idx), crypto.SHA256)
+			require.NoError(t, err)
+			require.NoError(t, VerifyTxInclusion(p, tb, crypto.SHA256))
+		}
+
+		// all transactions of the block
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 0, 1, 2, 3, 4, 5)
+		require.NoError(t, err)
+		require.Empty(t, proof.Siblings)
+		require.NoError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256))
+	})
+
+	t.Run("CBOR", func(t *testing.T) {
+		proof, err := NewTxMultiProof(block, crypto.SHA256, 2, 5)
+		require.NoError(t, err)
+		data, err := Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &TxMultiProof{}
+		require.NoError(t, Cbor.Unmarshal(data, proof2))
+		require.NoError(t, VerifyTxMultiInclusion(proof2, tb, crypto.SHA256))
+		require.ErrorContains(t, Cbor.Unmarshal(data, &TxProof{}), "expected tag")
+	})
+
+	t.Run("tampered proof", func(t *testing.T) {
+		proof, err := NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		proof.TxRecords[0], proof.TxRecords[1] = proof.TxRecords[1], proof.TxRecords[0]
+		require.EqualError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), "proof block hash does not match to block hash in unicity certificate")
+
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		proof.Siblings = proof.Siblings[1:]
+		require.EqualError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), "failed to evaluate merkle multi-path: not enough sibling hashes")
+
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		proof.TxCount = 4
+		require.EqualError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), "failed to evaluate merkle multi-path: 1 unused sibling hashes")
+
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		proof.BlockHeaderHash = []byte{1}
+		require.EqualError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), "proof block hash does not match to block hash in unicity certificate")
+
+		_, otherVerifier := testsig.CreateSignerAndVerifier(t)
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		require.ErrorContains(t, VerifyTxMultiInclusion(proof, NewTrustBase(t, otherVerifier), crypto.SHA256), "invalid unicity certificate")
+
+		// transactions are checked against the partition and network of the UC
+		proof, err = NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		txo := createTransactionOrder(t)
+		txo.NetworkID = NetworkTestNet
+		proof.TxRecords[1] = createTransactionRecord(t, txo, 1)
+		require.ErrorIs(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), ErrNetworkMismatch)
+		txo = createTransactionOrder(t)
+		txo.PartitionID = partitionID + 1
+		proof.TxRecords[1] = createTransactionRecord(t, txo, 1)
+		require.EqualError(t, VerifyTxMultiInclusion(proof, tb, crypto.SHA256), "transaction 1: transaction partition 01000002 does not match unicity certificate partition 01000001")
+	})
+
+	t.Run("UC is verified once", func(t *testing.T) {
+		proof, err := NewTxMultiProof(block, crypto.SHA256, 0, 1, 2, 3, 4, 5)
+		require.NoError(t, err)
+		ctb := &countingTrustBase{RootTrustBase: tb}
+		require.NoError(t, VerifyTxMultiInclusion(proof, ctb, crypto.SHA256))
+		require.Equal(t, 1, ctb.calls)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := NewTxMultiProof(nil, crypto.SHA256, 0)
+		require.ErrorIs(t, err, ErrBlockIsNil)
+		_, err = NewTxMultiProof(block, crypto.SHA256)
+		require.EqualError(t, err, "no transaction indexes")
+		_, err = NewTxMultiProof(block, crypto.SHA256, 6)
+		require.EqualError(t, err, "invalid tx index: 6")
+		_, err = NewTxMultiProof(block, crypto.SHA256, 1, 1)
+		require.EqualError(t, err, "duplicate tx index: 1")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var proof *TxMultiProof
+		require.ErrorIs(t, proof.IsValid(), ErrTxMultiProofIsNil)
+
+		proof, err := NewTxMultiProof(block, crypto.SHA256, 1, 3)
+		require.NoError(t, err)
+		require.NoError(t, proof.IsValid())
+
+		proof.Version = 2
+		require.ErrorContains(t, proof.IsValid(), "invalid version")
+		proof.Version = 1
+
+		proof.TxIndexes = []uint32{3, 1}
+		require.EqualError(t, proof.IsValid(), "tx indexes must be in ascending order without duplicates, got 1 after 3")
+		proof.TxIndexes = []uint32{1, 6}
+		require.EqualError(t, proof.IsValid(), "tx index 6 is out of range, block has 6 transactions")
+		proof.TxIndexes = []uint32{1}
+		require.EqualError(t, proof.IsValid(), "proof has 2 transaction records but 1 indexes")
+		proof.TxRecords, proof.TxIndexes = nil, nil
+		require.EqualError(t, proof.IsValid(), "no transaction records")
+	})
+}
+
+// countingTrustBase counts the signature verifications.
+type countingTrustBase struct {
+	RootTrustBase
+	calls int
+}
+
+func (tb *countingTrustBase) VerifyQuorumSignatures(data []byte, signatures map[string]hex.Bytes) error {
+	tb.calls++
+	return tb.RootTrustBase.VerifyQuorumSignatures(data, signatures)
+}
@@ -52,6 +52,41 @@ func NewTxRecordProof(block *Block, txIndex int, algorithm crypto.Hash) (*TxReco
 	if txIndex < 0 || txIndex > len(block.Transactions)-1 {
 		return nil, fmt.Errorf("invalid tx index: %d", txIndex)
 	}
+	proofs, err := NewTxRecordProofs(block, algorithm, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	return proofs[0], nil
+}
+
+/*
+NewTxRecordProofs returns proofs of the transactions with given indexes (in
+the same order as the indexes), when no index is given proofs of all the
+transactions of the block are returned.
+
+Unlike calling NewTxRecordProof for each transaction the Merkle tree of the
+transactions and the block header hash are calculated only once. The proofs
+share the block header hash and the UC bytes, they must not be modified.
+*/
+func NewTxRecordProofs(block *Block, algorithm crypto.Hash, txIndexes ...int) ([]*TxRecordProof, error) {
+	if block == nil {
+		return nil, ErrBlockIsNil
+	}
+	if len(txIndexes) == 0 {
+		txIndexes = make([]int, len(block.Transactions))
+		for i := range txIndexes {
+			txIndexes[i] = i
+		}
+	}
+	for _, idx := range txIndexes {
+		if idx < 0 || idx > len(block.Transactions)-1 {
+			return nil, fmt.Errorf("invalid tx index: %d", idx)
+		}
+	}
+	if len(txIndexes) == 0 {
+		return []*TxRecordProof{}, nil
+	}
+
 	tree, err := mt.New(algorithm, block.Transactions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create merkle tree: %w", err)
@@ -60,34 +95,100 @@ func NewTxRecordProof(block *Block, txIndex int, algorithm crypto.Hash) (*TxReco
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate block header hash: %w", err)
 	}
-	chain, err := tree.GetMerklePath(txIndex)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract merkle proof: %w", err)
-	}
-	items := make([]*GenericChainItem, len(chain))
-	for i, item := range chain {
-		items[i] = &GenericChainItem{
-			Left: item.DirectionLeft,
-			Hash: item.Hash,
+	proofs := make([]*TxRecordProof, len(txIndexes))
+	for i, idx := range txIndexes {
+		chain, err := tree.GetMerklePath(idx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract merkle proof: %w", err)
+		}
+		items := make([]*GenericChainItem, len(chain))
+		for i, item := range chain {
+			items[i] = &GenericChainItem{
+				Left: item.DirectionLeft,
+				Hash: item.Hash,
+			}
+		}
+		proofs[i] = &TxRecordProof{
+			TxRecord: block.Transactions[idx],
+			TxProof: &TxProof{
+				Version:            1,
+				BlockHeaderHash:    headerHash,
+				Chain:              items,
+				UnicityCertificate: block.UnicityCertificate,
+			},
 		}
 	}
-	return &TxRecordProof{
-		TxRecord: block.Transactions[txIndex],
-		TxProof: &TxProof{
-			Version:            1,
-			BlockHeaderHash:    headerHash,
-			Chain:              items,
-			UnicityCertificate: block.UnicityCertificate,
-		},
-	}, nil
+	return proofs, nil
 }
 
 // VerifyTxInclusion checks if the transaction is included in the block.
-func VerifyTxInclusion(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgorithm crypto.Hash) error {
+// The transaction must be of the same network as the unicity seal of the UC.
+//
+// The shard configuration of the UC is not verified, use VerifyTxInclusionWithPDR
+// or VerifyTxInclusionWithShardConf to verify the proof against the partition
+// description.
+func VerifyTxInclusion(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	return verifyTxInclusion(txRecordProof, tb, nil, hashAlgorithm, opts...)
+}
+
+/*
+VerifyTxInclusionWithPDR checks if the transaction is included in the block and
+that the block was certified for the shard configured by the "pdr", ie the UC's
+shard configuration hash must match the hash of the "pdr", the transaction must
+be of the network and partition of the "pdr" and the unit of the transaction
+must belong to the shard of the UC.
+*/
+func VerifyTxInclusionWithPDR(txRecordProof *TxRecordProof, tb RootTrustBase, pdr *PartitionDescriptionRecord, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if pdr == nil {
+		return errors.New("partition description record is nil")
+	}
+	shardConf := func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) { return pdr, nil }
+	return verifyTxInclusion(txRecordProof, tb, shardConf, hashAlgorithm, opts...)
+}
+
+/*
+VerifyTxInclusionWithShardConf is like VerifyTxInclusionWithPDR but the partition
+description record is acquired using "shardConf" for the partition and shard of
+the UC and the epoch of the UC's input record.
+*/
+func VerifyTxInclusionWithShardConf(txRecordProof *TxRecordProof, tb RootTrustBase, shardConf ShardConfLookup, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if shardConf == nil {
+		return errors.New("shard configuration lookup is nil")
+	}
+	return verifyTxInclusion(txRecordProof, tb, shardConf, hashAlgorithm, opts...)
+}
+
+func verifyTxInclusion(txRecordProof *TxRecordProof, tb RootTrustBase, shardConf ShardConfLookup, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
 	if err := txRecordProof.IsValid(); err != nil {
 		return err
 	}
-	txRecord := txRecordProof.TxRecord
+	uc, err := txRecordProof.TxProof.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate: %w", err)
+	}
+
+	txo, err := txRecordProof.TxRecord.GetTransactionOrderV1()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction order: %w", err)
+	}
+
+	var shardConfHash []byte
+	if shardConf != nil {
+		if shardConfHash, err = verifyTxShardConf(uc, txo, shardConf, hashAlgorithm); err != nil {
+			return err
+		}
+	}
+	if err := verifyTxCertificate(uc, txo, tb, shardConfHash, hashAlgorithm, opts...); err != nil {
+		return err
+	}
+	return verifyTxRecordPath(txRecordProof, uc, hashAlgorithm)
+}
+
+/*
+verifyTxRecordPath checks that the transaction record of the proof is included
+in the block certified by the "uc", the UC itself is not verified.
+*/
+func verifyTxRecordPath(txRecordProof *TxRecordProof, uc *UnicityCertificate, hashAlgorithm crypto.Hash) error {
 	txProof := txRecordProof.TxProof
 	merklePath := make([]*mt.PathItem, len(txProof.Chain))
 	for i, item := range txProof.Chain {
@@ -96,30 +197,63 @@ func VerifyTxInclusion(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgor
 			DirectionLeft: item.Left,
 		}
 	}
-	// TODO ch 2.8.7: Verify Transaction Proof: VerifyTxProof: System description must be an input parameter
-	uc, err := txProof.GetUC()
+	// h ← plain_tree_output(C, H(P))
+	rootHash, err := mt.EvalMerklePath(merklePath, txRecordProof.TxRecord, hashAlgorithm)
 	if err != nil {
-		return fmt.Errorf("failed to get unicity certificate: %w", err)
+		return fmt.Errorf("failed to evaluate merkle path: %w", err)
 	}
+	return verifyProofBlockHash(uc, txProof.BlockHeaderHash, rootHash, hashAlgorithm)
+}
 
-	txo, err := txRecord.GetTransactionOrderV1()
+/*
+verifyTxShardConf acquires the PDR of the UC's shard and epoch and checks that
+the transaction is valid for it. Returns the hash of the PDR which the UC must be
+verified against.
+*/
+func verifyTxShardConf(uc *UnicityCertificate, txo *TransactionOrder, shardConf ShardConfLookup, hashAlgorithm crypto.Hash) ([]byte, error) {
+	if uc.InputRecord == nil {
+		return nil, ErrInputRecordIsNil
+	}
+	if uc.UnicityTreeCertificate == nil {
+		return nil, ErrUnicityTreeCertificateIsNil
+	}
+	pdr, err := shardConf(uc.GetPartitionID(), uc.GetShardID(), uc.InputRecord.Epoch)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction order: %w", err)
+		return nil, fmt.Errorf("acquiring shard configuration of partition %s shard %q epoch %d: %w", uc.GetPartitionID(), uc.GetShardID(), uc.InputRecord.Epoch, err)
 	}
-
-	if err := uc.Verify(tb, hashAlgorithm, txo.PartitionID, nil); err != nil {
-		return fmt.Errorf("invalid unicity certificate: %w", err)
+	if pdr == nil {
+		return nil, errors.New("partition description record is nil")
 	}
-	// h ← plain_tree_output(C, H(P))
-	rootHash, err := mt.EvalMerklePath(merklePath, txRecord, hashAlgorithm)
+	pdrHash, err := pdr.Hash(hashAlgorithm)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate merkle path: %w", err)
+		return nil, fmt.Errorf("hashing shard configuration: %w", err)
+	}
+	if !bytes.Equal(pdrHash, uc.ShardConfHash) {
+		return nil, fmt.Errorf("shard configuration hash %X does not match the hash of the shard configuration %X", uc.ShardConfHash, pdrHash)
+	}
+	if txo.NetworkID != pdr.NetworkID {
+		return nil, fmt.Errorf("%w: transaction network %d does not match partition description network %d", ErrNetworkMismatch, txo.NetworkID, pdr.NetworkID)
+	}
+	if txo.PartitionID != pdr.PartitionID {
+		return nil, fmt.Errorf("transaction partition %s does not match partition description partition %s", txo.PartitionID, pdr.PartitionID)
 	}
+	if err := pdr.UnitIDValidator(uc.GetShardID())(txo.UnitID); err != nil {
+		return nil, fmt.Errorf("invalid transaction unit ID: %w", err)
+	}
+	return pdrHash, nil
+}
+
+/*
+verifyProofBlockHash checks that the block hash calculated from the header hash
+and the root hash of the transactions tree matches the block hash certified
+by the UC.
+*/
+func verifyProofBlockHash(uc *UnicityCertificate, headerHash, txRootHash []byte, hashAlgorithm crypto.Hash) error {
 	hasher := abhash.New(hashAlgorithm.New())
-	hasher.Write(txProof.BlockHeaderHash)
+	hasher.Write(headerHash)
 	hasher.Write(uc.InputRecord.PreviousHash)
 	hasher.Write(uc.InputRecord.Hash)
-	hasher.Write(rootHash)
+	hasher.Write(txRootHash)
 	//h ← H(h_h,h)
 	blockHash, err := hasher.Sum()
 	if err != nil {
@@ -133,9 +267,35 @@ func VerifyTxInclusion(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgor
 	return nil
 }
 
+/*
+verifyTxCertificate checks that the UC is valid and that the transaction is
+of the partition and network of the UC. When "shardConfHash" is not nil the UC
+must be issued for the shard configuration with that hash.
+*/
+func verifyTxCertificate(uc *UnicityCertificate, txo *TransactionOrder, tb RootTrustBase, shardConfHash []byte, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := uc.Verify(tb, hashAlgorithm, txo.PartitionID, shardConfHash, opts...); err != nil {
+		return fmt.Errorf("invalid unicity certificate: %w", err)
+	}
+	return checkTxOfCertificate(uc, txo)
+}
+
+/*
+checkTxOfCertificate checks that the transaction is of the partition and network
+of the (already verified) UC.
+*/
+func checkTxOfCertificate(uc *UnicityCertificate, txo *TransactionOrder) error {
+	if txo.PartitionID != uc.GetPartitionID() {
+		return fmt.Errorf("transaction partition %s does not match unicity certificate partition %s", txo.PartitionID, uc.GetPartitionID())
+	}
+	if txo.NetworkID != uc.UnicitySeal.NetworkID {
+		return fmt.Errorf("%w: transaction network %d does not match unicity seal network %d", ErrNetworkMismatch, txo.NetworkID, uc.UnicitySeal.NetworkID)
+	}
+	return nil
+}
+
 // VerifyTxProof checks if the transaction is included in the block and was successfully executed.
-func VerifyTxProof(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgorithm crypto.Hash) error {
-	if err := VerifyTxInclusion(txRecordProof, tb, hashAlgorithm); err != nil {
+func VerifyTxProof(txRecordProof *TxRecordProof, tb RootTrustBase, hashAlgorithm crypto.Hash, opts ...VerifyOption) error {
+	if err := VerifyTxInclusion(txRecordProof, tb, hashAlgorithm, opts...); err != nil {
 		return fmt.Errorf("verify tx inclusion: %w", err)
 	}
 	if !txRecordProof.TxRecord.IsSuccessful() {
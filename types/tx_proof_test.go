@@ -2,11 +2,14 @@ package types
 
 import (
 	"crypto"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	abcrypto "github.com/alphabill-org/alphabill-go-base/crypto"
 	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,6 +42,95 @@ func TestNewTxProof(t *testing.T) {
 	})
 }
 
+func TestNewTxRecordProofs(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	block := createBlock(t, "test", signer, createTx(t), createTx(t), createTx(t), createTx(t), createTx(t))
+
+	t.Run("all transactions", func(t *testing.T) {
+		proofs, err := NewTxRecordProofs(block, crypto.SHA256)
+		require.NoError(t, err)
+		require.Len(t, proofs, len(block.Transactions))
+		tree, err := mt.New(crypto.SHA256, block.Transactions)
+		require.NoError(t, err)
+		headerHash, err := block.HeaderHash(crypto.SHA256)
+		require.NoError(t, err)
+		for i, p := range proofs {
+			path, err := tree.GetMerklePath(i)
+			require.NoError(t, err)
+			require.Len(t, p.TxProof.Chain, len(path))
+			for j, item := range path {
+				require.Equal(t, item.DirectionLeft, p.TxProof.Chain[j].Left)
+				require.EqualValues(t, item.Hash, p.TxProof.Chain[j].Hash)
+			}
+			require.Equal(t, block.Transactions[i], p.TxRecord)
+			require.Equal(t, headerHash, p.TxProof.BlockHeaderHash)
+			require.Equal(t, block.UnicityCertificate, p.TxProof.UnicityCertificate)
+			require.NoError(t, VerifyTxInclusion(p, tb, crypto.SHA256))
+		}
+	})
+
+	t.Run("selected transactions", func(t *testing.T) {
+		proofs, err := NewTxRecordProofs(block, crypto.SHA256, 4, 1, 4)
+		require.NoError(t, err)
+		require.Len(t, proofs, 3)
+		for i, idx := range []int{4, 1, 4} {
+			require.Equal(t, block.Transactions[idx], proofs[i].TxRecord)
+			require.NoError(t, VerifyTxInclusion(proofs[i], tb, crypto.SHA256))
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := NewTxRecordProofs(nil, crypto.SHA256)
+		require.ErrorIs(t, err, ErrBlockIsNil)
+
+		_, err = NewTxRecordProofs(block, crypto.SHA256, 0, 5)
+		require.EqualError(t, err, "invalid tx index: 5")
+		_, err = NewTxRecordProofs(block, crypto.SHA256, -1)
+		require.EqualError(t, err, "invalid tx index: -1")
+
+		proofs, err := NewTxRecordProofs(&Block{Header: block.Header}, crypto.SHA256)
+		require.NoError(t, err)
+		require.Empty(t, proofs)
+	})
+}
+
+func BenchmarkTxRecordProofs(b *testing.B) {
+	for _, txCount := range []int{10, 100, 1000} {
+		block := &Block{
+			Header:             &Header{Version: 1, PartitionID: partitionID, ProposerID: "proposer"},
+			UnicityCertificate: []byte{0xF6},
+		}
+		for i := range txCount {
+			txo := &TransactionOrder{Version: 1, Payload: Payload{NetworkID: 1, PartitionID: partitionID, UnitID: []byte{byte(i >> 8), byte(i)}, Type: 1}}
+			txoBytes, err := txo.MarshalCBOR()
+			require.NoError(b, err)
+			block.Transactions = append(block.Transactions, &TransactionRecord{
+				Version:          1,
+				TransactionOrder: txoBytes,
+				ServerMetadata:   &ServerMetadata{ActualFee: 1, SuccessIndicator: TxStatusSuccessful},
+			})
+		}
+
+		b.Run(fmt.Sprintf("NewTxRecordProof/%d", txCount), func(b *testing.B) {
+			for range b.N {
+				for i := range block.Transactions {
+					if _, err := NewTxRecordProof(block, i, crypto.SHA256); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("NewTxRecordProofs/%d", txCount), func(b *testing.B) {
+			for range b.N {
+				if _, err := NewTxRecordProofs(block, crypto.SHA256); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func TestVerifyInc(t *testing.T) {
 	t.Run("Test ok", func(t *testing.T) {
 		signer, verifier := testsig.CreateSignerAndVerifier(t)
@@ -89,6 +181,22 @@ func TestVerifyInc(t *testing.T) {
 			"invalid unicity certificate: invalid unicity certificate: invalid unicity tree certificate: invalid partition identifier: expected 01000001, got 00000001")
 	})
 
+	t.Run("Test invalid network id", func(t *testing.T) {
+		signer, verifier := testsig.CreateSignerAndVerifier(t)
+		txo := createTransactionOrder(t)
+		txo.NetworkID = NetworkTestNet
+		block := createBlock(t, "test", signer, createTransactionRecord(t, txo, 1))
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		tb := NewTrustBase(t, verifier)
+
+		err = VerifyTxInclusion(proof, tb, crypto.SHA256)
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "network mismatch: transaction network 2 does not match unicity seal network 1")
+		// accepting other networks doesn't allow transaction of one network to be certified in another
+		require.ErrorIs(t, VerifyTxInclusion(proof, tb, crypto.SHA256, WithAcceptedNetworks(NetworkMainNet, NetworkTestNet)), ErrNetworkMismatch)
+	})
+
 	t.Run("Test invalid block hash", func(t *testing.T) {
 		signer, verifier := testsig.CreateSignerAndVerifier(t)
 		block := createBlock(t, "test", signer, createTx(t))
@@ -101,6 +209,89 @@ func TestVerifyInc(t *testing.T) {
 	})
 }
 
+func TestVerifyTxInclusionWithPDR(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: partitionID,
+		TypeIDLen:   8,
+		UnitIDLen:   248,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	block := createBlockWithPDR(t, "test", signer, pdr, createTx(t))
+	proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, VerifyTxInclusionWithPDR(proof, tb, pdr, crypto.SHA256))
+
+		var lookupArgs []any
+		shardConf := func(partition PartitionID, shard ShardID, epoch uint64) (*PartitionDescriptionRecord, error) {
+			lookupArgs = []any{partition, shard, epoch}
+			return pdr, nil
+		}
+		require.NoError(t, VerifyTxInclusionWithShardConf(proof, tb, shardConf, crypto.SHA256))
+		require.Equal(t, []any{partitionID, ShardID{}, uint64(0)}, lookupArgs)
+	})
+
+	t.Run("missing PDR", func(t *testing.T) {
+		require.EqualError(t, VerifyTxInclusionWithPDR(proof, tb, nil, crypto.SHA256), "partition description record is nil")
+		require.EqualError(t, VerifyTxInclusionWithShardConf(proof, tb, nil, crypto.SHA256), "shard configuration lookup is nil")
+
+		shardConf := func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) {
+			return nil, errors.New("not found")
+		}
+		require.EqualError(t, VerifyTxInclusionWithShardConf(proof, tb, shardConf, crypto.SHA256),
+			`acquiring shard configuration of partition 01000001 shard "" epoch 0: not found`)
+	})
+
+	t.Run("shard configuration hash mismatch", func(t *testing.T) {
+		other := *pdr
+		other.T2Timeout = time.Second
+		require.ErrorContains(t, VerifyTxInclusionWithPDR(proof, tb, &other, crypto.SHA256), "does not match the hash of the shard configuration")
+		// the PDR the block was not certified with is not accepted
+		block := createBlock(t, "test", signer, createTx(t))
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		require.ErrorContains(t, VerifyTxInclusionWithPDR(proof, tb, pdr, crypto.SHA256), "does not match the hash of the shard configuration")
+	})
+
+	t.Run("transaction of other network", func(t *testing.T) {
+		txo := createTransactionOrder(t)
+		txo.NetworkID = NetworkTestNet
+		block := createBlockWithPDR(t, "test", signer, pdr, createTransactionRecord(t, txo, 1))
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		err = VerifyTxInclusionWithPDR(proof, tb, pdr, crypto.SHA256)
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "network mismatch: transaction network 2 does not match partition description network 1")
+	})
+
+	t.Run("transaction of other partition", func(t *testing.T) {
+		txo := createTransactionOrder(t)
+		txo.PartitionID = partitionID + 1
+		block := createBlockWithPDR(t, "test", signer, pdr, createTransactionRecord(t, txo, 1))
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, VerifyTxInclusionWithPDR(proof, tb, pdr, crypto.SHA256),
+			"transaction partition 01000002 does not match partition description partition 01000001")
+	})
+
+	t.Run("invalid unit ID", func(t *testing.T) {
+		txo := createTransactionOrder(t)
+		txo.UnitID = make([]byte, 33)
+		block := createBlockWithPDR(t, "test", signer, pdr, createTransactionRecord(t, txo, 1))
+		proof, err := NewTxRecordProof(block, 0, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, VerifyTxInclusionWithPDR(proof, tb, pdr, crypto.SHA256),
+			"invalid transaction unit ID: expected 32 byte unit ID, got 33 bytes")
+		// without PDR the unit ID is not checked
+		require.NoError(t, VerifyTxInclusion(proof, tb, crypto.SHA256))
+	})
+}
+
 func TestVerifyTxProof(t *testing.T) {
 	t.Run("Test VerifyTxProof ok", func(t *testing.T) {
 		signer, verifier := testsig.CreateSignerAndVerifier(t)
@@ -143,6 +334,10 @@ func createBlock(t *testing.T, id string, signer abcrypto.Signer, txs ...*Transa
 		PartitionID: partitionID,
 		T2Timeout:   2500 * time.Millisecond,
 	}
+	return createBlockWithPDR(t, id, signer, sdrs, txs...)
+}
+
+func createBlockWithPDR(t *testing.T, id string, signer abcrypto.Signer, sdrs *PartitionDescriptionRecord, txs ...*TransactionRecord) *Block {
 	inputRecord := &InputRecord{
 		Version:         1,
 		PreviousHash:    []byte{0, 0, 1},
@@ -152,12 +347,17 @@ func createBlock(t *testing.T, id string, signer abcrypto.Signer, txs ...*Transa
 		SumOfEarnedFees: 2,
 		Timestamp:       NewTimestamp(),
 	}
+	return createBlockWithIR(t, id, signer, sdrs, inputRecord, txs...)
+}
+
+// createBlockWithIR returns block certified with the "inputRecord", the block hash of the IR is calculated.
+func createBlockWithIR(t *testing.T, id string, signer abcrypto.Signer, sdrs *PartitionDescriptionRecord, inputRecord *InputRecord, txs ...*TransactionRecord) *Block {
 	uc, err := (&UnicityCertificate{Version: 1, InputRecord: inputRecord}).MarshalCBOR()
 	require.NoError(t, err)
 	block := &Block{
 		Header: &Header{
 			Version:           1,
-			PartitionID:       partitionID,
+			PartitionID:       sdrs.PartitionID,
 			ProposerID:        "proposer123",
 			PreviousBlockHash: []byte{1, 2, 3},
 		},
@@ -68,6 +68,13 @@ func (t *TransactionRecord) UnmarshalProcessingDetails(v any) error {
 	return t.ServerMetadata.UnmarshalDetails(v)
 }
 
+func (t *TransactionRecord) TxError() (*TxError, error) {
+	if t == nil {
+		return nil, ErrTransactionRecordIsNil
+	}
+	return t.ServerMetadata.TxError()
+}
+
 func (t *TransactionRecord) GetActualFee() uint64 {
 	if t == nil {
 		return 0
@@ -167,9 +174,14 @@ func (sm *ServerMetadata) UnmarshalDetails(v any) error {
 	return Cbor.Unmarshal(sm.ProcessingDetails, v)
 }
 
-func (sm *ServerMetadata) SetError(e error) {
+/*
+SetError marks the transaction as failed and stores the description of the error
+(see TxError) as the processing details of the transaction. Error is returned
+when encoding the description fails, the transaction is marked as failed anyway.
+*/
+func (sm *ServerMetadata) SetError(e error) error {
 	if sm == nil {
-		return
+		return errors.New("server metadata is nil")
 	}
 	// on error clear changed units
 	if errors.Is(e, ErrOutOfGas) {
@@ -179,6 +191,34 @@ func (sm *ServerMetadata) SetError(e error) {
 	}
 	sm.TargetUnits = []UnitID{}
 	sm.errDetail = e
+	sm.ProcessingDetails = nil
+	if e == nil {
+		return nil
+	}
+	details, err := Cbor.Marshal(txErrorOf(e))
+	if err != nil {
+		return fmt.Errorf("encoding transaction error: %w", err)
+	}
+	sm.ProcessingDetails = details
+	return nil
+}
+
+/*
+TxError returns the description of the failure of the transaction, nil is returned
+when the transaction was successful or the failure was not described.
+*/
+func (sm *ServerMetadata) TxError() (*TxError, error) {
+	if sm == nil {
+		return nil, errors.New("server metadata is nil")
+	}
+	if sm.SuccessIndicator == TxStatusSuccessful || len(sm.ProcessingDetails) == 0 {
+		return nil, nil
+	}
+	txErr := &TxError{}
+	if err := sm.UnmarshalDetails(txErr); err != nil {
+		return nil, fmt.Errorf("decoding transaction error: %w", err)
+	}
+	return txErr, nil
 }
 
 func (sm *ServerMetadata) ErrDetail() error {
@@ -195,7 +235,7 @@ func (sm *ServerMetadata) GetTargetUnits() []UnitID {
 	return sm.TargetUnits
 }
 
-func (t *TxRecordProof) Verify(getTrustBase func(epoch uint64) (RootTrustBase, error)) error {
+func (t *TxRecordProof) Verify(getTrustBase func(epoch uint64) (RootTrustBase, error), opts ...VerifyOption) error {
 	if t == nil || t.TxProof == nil {
 		return errors.New("invalid TxRecordProof (nil or txProof is nil)")
 	}
@@ -210,7 +250,7 @@ func (t *TxRecordProof) Verify(getTrustBase func(epoch uint64) (RootTrustBase, e
 	if err != nil {
 		return fmt.Errorf("acquiring trust base: %w", err)
 	}
-	return VerifyTxProof(t, trustBase, crypto.SHA256)
+	return VerifyTxProof(t, trustBase, crypto.SHA256, opts...)
 }
 
 func (t *TxRecordProof) IsValid() error {
@@ -0,0 +1,169 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrTxUnitStateProofIsNil = errors.New("transaction and unit state proof is nil")
+
+/*
+TxUnitStateProof proves that the transaction was executed and that, as the result
+of the execution, the unit was in the given state at the end of the round. Ie
+"this transfer happened and after it the bill is owned by X with value V".
+
+The transaction record proof and the unit state proof must be certified for the
+same round and the unit state proof must be the proof of the state created by
+the transaction, see TxUnitStateProof.Verify.
+*/
+type TxUnitStateProof struct {
+	_              struct{}        `cbor:",toarray"`
+	Version        ABVersion       `json:"version"`
+	TxRecordProof  *TxRecordProof  `json:"txRecordProof"`
+	UnitStateProof *UnitStateProof `json:"unitStateProof"`
+	UnitState      *UnitState      `json:"unitState"`
+}
+
+/*
+Verify checks that:
+  - the transaction is included in the block certified by the UC of the transaction
+    proof and it was executed successfully;
+  - the unit state is proven by the unit state proof (see UnitStateProof.Verify);
+  - both proofs are certified for the same round of the same shard;
+  - the transaction targeted the unit and the unit state proof is for the state
+    created by the transaction, ie UnitTreeCert.TransactionRecordHash is the hash
+    of the transaction record.
+
+Both UCs are validated using "ucv".
+*/
+func (p *TxUnitStateProof) Verify(algorithm crypto.Hash, ucv UnicityCertificateValidator, shardConfHash []byte) error {
+	if err := p.IsValid(); err != nil {
+		return fmt.Errorf("invalid proof: %w", err)
+	}
+	if ucv == nil {
+		return errors.New("unicity certificate validator is nil")
+	}
+
+	txUC, err := p.TxRecordProof.TxProof.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate of the transaction proof: %w", err)
+	}
+	if err := ucv.Validate(txUC, shardConfHash); err != nil {
+		return fmt.Errorf("invalid unicity certificate of the transaction proof: %w", err)
+	}
+	unitUC, err := p.UnitStateProof.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate of the unit state proof: %w", err)
+	}
+	if err := checkSameRound(txUC, unitUC); err != nil {
+		return err
+	}
+
+	txo, err := p.TxRecordProof.GetTransactionOrderV1()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction order: %w", err)
+	}
+	if txo.PartitionID != txUC.GetPartitionID() {
+		return fmt.Errorf("transaction partition %s does not match unicity certificate partition %s", txo.PartitionID, txUC.GetPartitionID())
+	}
+	if txo.NetworkID != txUC.UnicitySeal.NetworkID {
+		return fmt.Errorf("%w: transaction network %d does not match unicity seal network %d", ErrNetworkMismatch, txo.NetworkID, txUC.UnicitySeal.NetworkID)
+	}
+	if err := verifyTxRecordPath(p.TxRecordProof, txUC, algorithm); err != nil {
+		return fmt.Errorf("verify tx inclusion: %w", err)
+	}
+	if !p.TxRecordProof.TxRecord.IsSuccessful() {
+		return errors.New("transaction failed")
+	}
+
+	if err := p.UnitStateProof.Verify(algorithm, p.UnitState, ucv, shardConfHash); err != nil {
+		return fmt.Errorf("verify unit state proof: %w", err)
+	}
+
+	unitID := p.UnitStateProof.UnitID
+	if !slices.ContainsFunc(p.TxRecordProof.TxRecord.TargetUnits(), unitID.Eq) {
+		return fmt.Errorf("unit %s is not targeted by the transaction", unitID)
+	}
+	txrHash, err := p.TxRecordProof.TxRecord.Hash(algorithm)
+	if err != nil {
+		return fmt.Errorf("hashing transaction record: %w", err)
+	}
+	if !bytes.Equal(txrHash, p.UnitStateProof.UnitTreeCert.TransactionRecordHash) {
+		return fmt.Errorf("unit state is not created by the transaction: transaction record hash %X, unit tree cert has %X", txrHash, p.UnitStateProof.UnitTreeCert.TransactionRecordHash)
+	}
+	return nil
+}
+
+/*
+checkSameRound checks that the UCs certify the same round of the same shard. The
+UCs may be issued in different root rounds (repeat UC) but they must certify the
+same input record.
+*/
+func checkSameRound(a, b *UnicityCertificate) error {
+	if b.InputRecord == nil {
+		return ErrInputRecordIsNil
+	}
+	if b.UnicityTreeCertificate == nil {
+		return ErrUnicityTreeCertificateIsNil
+	}
+	if a.GetPartitionID() != b.GetPartitionID() || !a.GetShardID().Equal(b.GetShardID()) {
+		return fmt.Errorf("proofs are for different shards: transaction proof is for %s-%s, unit state proof is for %s-%s",
+			a.GetPartitionID(), a.GetShardID(), b.GetPartitionID(), b.GetShardID())
+	}
+	if a.GetRoundNumber() != b.GetRoundNumber() {
+		return fmt.Errorf("proofs are for different rounds: transaction proof is for round %d, unit state proof is for round %d", a.GetRoundNumber(), b.GetRoundNumber())
+	}
+	eq, err := EqualIR(a.InputRecord, b.InputRecord)
+	if err != nil {
+		return fmt.Errorf("comparing input records: %w", err)
+	}
+	if !eq {
+		return errors.New("proofs certify different input records of the same round")
+	}
+	return nil
+}
+
+func (p *TxUnitStateProof) IsValid() error {
+	if p == nil {
+		return ErrTxUnitStateProofIsNil
+	}
+	if p.Version != 1 {
+		return ErrInvalidVersion(p)
+	}
+	if err := p.TxRecordProof.IsValid(); err != nil {
+		return err
+	}
+	if err := p.UnitStateProof.IsValid(); err != nil {
+		return err
+	}
+	if p.UnitState == nil {
+		return errors.New("unit state is nil")
+	}
+	return nil
+}
+
+func (p *TxUnitStateProof) GetVersion() ABVersion {
+	if p != nil && p.Version > 0 {
+		return p.Version
+	}
+	return 1
+}
+
+func (p *TxUnitStateProof) MarshalCBOR() ([]byte, error) {
+	type alias TxUnitStateProof
+	if p.Version == 0 {
+		p.Version = p.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(TxUnitStateProofTag, (*alias)(p))
+}
+
+func (p *TxUnitStateProof) UnmarshalCBOR(data []byte) error {
+	type alias TxUnitStateProof
+	if err := Cbor.UnmarshalTaggedValue(TxUnitStateProofTag, data, (*alias)(p)); err != nil {
+		return err
+	}
+	return EnsureVersion(p, p.Version, 1)
+}
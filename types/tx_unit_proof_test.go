@@ -0,0 +1,153 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+func TestTxUnitStateProof(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: partitionID,
+		TypeIDLen:   8,
+		UnitIDLen:   248,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	ucv, err := NewUCValidator(
+		func(uint64) (RootTrustBase, error) { return tb, nil },
+		func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) { return pdr, nil },
+		crypto.SHA256)
+	require.NoError(t, err)
+
+	// txA and txB target the unit, the unit state is the result of txA; txC targets other unit
+	newTx := func(fee uint64, units ...UnitID) *TransactionRecord {
+		txr := createTransactionRecord(t, createTransactionOrder(t), fee)
+		txr.ServerMetadata.TargetUnits = units
+		return txr
+	}
+	txA, txB, txC := newTx(1, unitID), newTx(2, unitID), newTx(3, UnitID{1})
+	txAHash, err := txA.Hash(crypto.SHA256)
+	require.NoError(t, err)
+
+	data, err := Cbor.Marshal([]uint64{100})
+	require.NoError(t, err)
+	unitState := &UnitState{Data: data}
+	newUnitProof := func() *UnitStateProof {
+		return &UnitStateProof{
+			Version:        1,
+			UnitID:         unitID,
+			UnitValue:      100,
+			UnitLedgerHash: make([]byte, 32),
+			UnitTreeCert: &UnitTreeCert{
+				TransactionRecordHash: txAHash,
+				UnitStateHash:         doHash(t, unitState),
+			},
+			StateTreeCert: &StateTreeCert{},
+		}
+	}
+	stateRoot, summary, err := newUnitProof().CalculateStateTreeOutput(crypto.SHA256)
+	require.NoError(t, err)
+	newBlock := func(round uint64) *Block {
+		ir := &InputRecord{
+			Version:      1,
+			PreviousHash: []byte{0, 0, 1},
+			Hash:         stateRoot,
+			SummaryValue: util.Uint64ToBytes(summary),
+			RoundNumber:  round,
+			Timestamp:    NewTimestamp(),
+		}
+		return createBlockWithIR(t, "test", signer, pdr, ir, txA, txB, txC)
+	}
+	block := newBlock(5)
+
+	newProof := func(txIdx int) *TxUnitStateProof {
+		txProof, err := NewTxRecordProof(block, txIdx, crypto.SHA256)
+		require.NoError(t, err)
+		unitProof := newUnitProof()
+		unitProof.UnicityCertificate = block.UnicityCertificate
+		return &TxUnitStateProof{Version: 1, TxRecordProof: txProof, UnitStateProof: unitProof, UnitState: unitState}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		proof := newProof(0)
+		require.NoError(t, proof.Verify(crypto.SHA256, ucv, nil))
+
+		data, err := Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &TxUnitStateProof{}
+		require.NoError(t, Cbor.Unmarshal(data, proof2))
+		require.NoError(t, proof2.Verify(crypto.SHA256, ucv, nil))
+	})
+
+	t.Run("unit state not created by the transaction", func(t *testing.T) {
+		require.ErrorContains(t, newProof(1).Verify(crypto.SHA256, ucv, nil), "unit state is not created by the transaction")
+	})
+
+	t.Run("unit not targeted by the transaction", func(t *testing.T) {
+		require.EqualError(t, newProof(2).Verify(crypto.SHA256, ucv, nil), "unit 0000000000000000000000000000000000000000000000000000000000000000 is not targeted by the transaction")
+	})
+
+	t.Run("proofs of different rounds", func(t *testing.T) {
+		proof := newProof(0)
+		proof.UnitStateProof.UnicityCertificate = newBlock(6).UnicityCertificate
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucv, nil), "proofs are for different rounds: transaction proof is for round 5, unit state proof is for round 6")
+	})
+
+	t.Run("proofs of different shards", func(t *testing.T) {
+		proof := newProof(0)
+		uc, err := proof.UnitStateProof.GetUC()
+		require.NoError(t, err)
+		uc.UnicityTreeCertificate.Partition = partitionID + 1
+		proof.UnitStateProof.UnicityCertificate, err = uc.MarshalCBOR()
+		require.NoError(t, err)
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucv, nil), "proofs are for different shards: transaction proof is for 01000001-, unit state proof is for 01000002-")
+	})
+
+	t.Run("invalid unit state", func(t *testing.T) {
+		proof := newProof(0)
+		proof.UnitState = &UnitState{Data: data, DeletionRound: 1}
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucv, nil), "verify unit state proof: unit state hash does not match unit state hash in unit tree cert")
+	})
+
+	t.Run("invalid transaction proof", func(t *testing.T) {
+		proof := newProof(0)
+		proof.TxRecordProof.TxRecord = txB
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucv, nil), "verify tx inclusion: proof block hash does not match to block hash in unicity certificate")
+	})
+
+	t.Run("invalid UC", func(t *testing.T) {
+		require.EqualError(t, newProof(0).Verify(crypto.SHA256, alwaysInvalid{}, nil), "invalid unicity certificate of the transaction proof: invalid uc")
+		require.EqualError(t, newProof(0).Verify(crypto.SHA256, nil, nil), "unicity certificate validator is nil")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var proof *TxUnitStateProof
+		require.ErrorIs(t, proof.IsValid(), ErrTxUnitStateProofIsNil)
+
+		proof = newProof(0)
+		proof.Version = 2
+		require.ErrorContains(t, proof.IsValid(), "invalid version")
+
+		proof = newProof(0)
+		proof.TxRecordProof = nil
+		require.EqualError(t, proof.IsValid(), "transaction record proof is nil")
+
+		proof = newProof(0)
+		proof.UnitStateProof = nil
+		require.EqualError(t, proof.IsValid(), "unit state proof is nil")
+
+		proof = newProof(0)
+		proof.UnitState = nil
+		require.EqualError(t, proof.IsValid(), "unit state is nil")
+		require.EqualError(t, proof.Verify(crypto.SHA256, ucv, nil), "invalid proof: unit state is nil")
+	})
+}
@@ -0,0 +1,206 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrStaleUC        = errors.New("stale unicity certificate")
+	ErrEquivocatingUC = errors.New("equivocating unicity certificate")
+)
+
+const (
+	// UCFirstSeen - first UC accepted by the tracker for the shard, there is nothing
+	// to compare it with. Not to be confused with UnicityCertificate.IsInitial.
+	UCFirstSeen UCClass = iota + 1
+	// UCNewRound - UC certifies newer shard round than the latest UC.
+	UCNewRound
+	// UCRepeat - UC repeats the input record of the latest UC in a newer root round.
+	UCRepeat
+	// UCDuplicate - UC is from the same root round as the latest UC, ie it has been seen already.
+	UCDuplicate
+)
+
+type (
+	// UCClass is the classification of the UC in relation to the latest UC of the shard.
+	UCClass uint8
+
+	/*
+		UCStore is the persistent storage of the latest UC of the shards.
+	*/
+	UCStore interface {
+		// LoadLatestUC returns the latest UC of the shard or nil when there is
+		// no UC for the shard in the store.
+		LoadLatestUC(id PartitionShardID) (*UnicityCertificate, error)
+		// StoreLatestUC replaces the latest UC of the shard.
+		StoreLatestUC(id PartitionShardID, uc *UnicityCertificate) error
+	}
+
+	/*
+		UCTracker keeps track of the latest UC of each partition shard and accepts
+		new UC only when it doesn't equivocate with the latest one.
+
+		The tracker does not verify UCs, it is the callers responsibility to
+		verify the UC (ie using UnicityCertificateValidator) before passing it
+		to the tracker.
+	*/
+	UCTracker struct {
+		store  UCStore
+		latest map[PartitionShardID]*UnicityCertificate // cache of the latest UCs
+		mu     sync.Mutex
+	}
+
+	memoryUCStore struct {
+		ucs map[PartitionShardID]*UnicityCertificate
+		mu  sync.Mutex
+	}
+)
+
+/*
+NewUCTracker returns UC tracker which persists the latest UCs in the "store".
+When "store" is nil in-memory store is used.
+*/
+func NewUCTracker(store UCStore) *UCTracker {
+	if store == nil {
+		store = NewMemoryUCStore()
+	}
+	return &UCTracker{
+		store:  store,
+		latest: make(map[PartitionShardID]*UnicityCertificate),
+	}
+}
+
+/*
+Update classifies the UC in relation to the latest UC of the same partition
+shard and makes it the latest UC of the shard unless it is a duplicate.
+
+Error is returned (and UC is not accepted) when the UC is older than the latest
+UC (ErrStaleUC) or is equivocating with it (ErrEquivocatingUC).
+*/
+func (t *UCTracker) Update(uc *UnicityCertificate) (UCClass, error) {
+	if uc == nil {
+		return 0, ErrUCIsNil
+	}
+	if uc.InputRecord == nil {
+		return 0, ErrInputRecordIsNil
+	}
+	if uc.UnicitySeal == nil {
+		return 0, ErrUnicitySealIsNil
+	}
+	if uc.UnicityTreeCertificate == nil {
+		return 0, ErrUnicityTreeCertificateIsNil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := PartitionShardID{PartitionID: uc.GetPartitionID(), ShardID: uc.GetShardID().Key()}
+	prev, err := t.getLatest(id)
+	if err != nil {
+		return 0, err
+	}
+
+	class, err := classifyUC(prev, uc)
+	if err != nil {
+		return 0, err
+	}
+	if class != UCDuplicate {
+		if err := t.store.StoreLatestUC(id, uc); err != nil {
+			return 0, fmt.Errorf("storing the latest UC of %s: %w", id.String(), err)
+		}
+		t.latest[id] = uc
+	}
+	return class, nil
+}
+
+/*
+Latest returns the latest accepted UC of the shard or nil when no UC has been
+accepted for the shard.
+*/
+func (t *UCTracker) Latest(partition PartitionID, shard ShardID) (*UnicityCertificate, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.getLatest(PartitionShardID{PartitionID: partition, ShardID: shard.Key()})
+}
+
+func (t *UCTracker) getLatest(id PartitionShardID) (*UnicityCertificate, error) {
+	if uc, ok := t.latest[id]; ok {
+		return uc, nil
+	}
+	uc, err := t.store.LoadLatestUC(id)
+	if err != nil {
+		return nil, fmt.Errorf("loading the latest UC of %s: %w", id.String(), err)
+	}
+	if uc != nil {
+		t.latest[id] = uc
+	}
+	return uc, nil
+}
+
+func classifyUC(prev, uc *UnicityCertificate) (UCClass, error) {
+	if prev == nil {
+		return UCFirstSeen, nil
+	}
+	if uc.GetRootRoundNumber() < prev.GetRootRoundNumber() {
+		return 0, fmt.Errorf("%w: root round %d is older than the latest root round %d", ErrStaleUC, uc.GetRootRoundNumber(), prev.GetRootRoundNumber())
+	}
+	if uc.IsDuplicate(prev) {
+		eq, err := EqualIR(prev.InputRecord, uc.InputRecord)
+		if err != nil {
+			return 0, fmt.Errorf("comparing input records: %w", err)
+		}
+		if !eq || !bytes.Equal(prev.UnicitySeal.Hash, uc.UnicitySeal.Hash) {
+			return 0, fmt.Errorf("%w: different certificates for the same root round %d", ErrEquivocatingUC, uc.GetRootRoundNumber())
+		}
+		return UCDuplicate, nil
+	}
+	if err := CheckNonEquivocatingCertificates(prev, uc); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrEquivocatingUC, err)
+	}
+	isRepeat, err := uc.IsRepeat(prev)
+	if err != nil {
+		return 0, fmt.Errorf("checking for repeat UC: %w", err)
+	}
+	if isRepeat {
+		return UCRepeat, nil
+	}
+	return UCNewRound, nil
+}
+
+func (c UCClass) String() string {
+	switch c {
+	case UCFirstSeen:
+		return "first seen"
+	case UCNewRound:
+		return "new round"
+	case UCRepeat:
+		return "repeat"
+	case UCDuplicate:
+		return "duplicate"
+	default:
+		return fmt.Sprintf("UCClass(%d)", uint8(c))
+	}
+}
+
+/*
+NewMemoryUCStore returns UCStore implementation which keeps the UCs in memory.
+*/
+func NewMemoryUCStore() UCStore {
+	return &memoryUCStore{ucs: make(map[PartitionShardID]*UnicityCertificate)}
+}
+
+func (s *memoryUCStore) LoadLatestUC(id PartitionShardID) (*UnicityCertificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ucs[id], nil
+}
+
+func (s *memoryUCStore) StoreLatestUC(id PartitionShardID, uc *UnicityCertificate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ucs[id] = uc
+	return nil
+}
@@ -0,0 +1,170 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUCTracker_Update(t *testing.T) {
+	const partitionID PartitionID = 7
+	newUC := func(rootRound, round uint64, prevHash, hash, blockHash []byte) *UnicityCertificate {
+		return &UnicityCertificate{
+			Version: 1,
+			InputRecord: &InputRecord{
+				Version:      1,
+				RoundNumber:  round,
+				PreviousHash: prevHash,
+				Hash:         hash,
+				BlockHash:    blockHash,
+				SummaryValue: []byte{1},
+			},
+			UnicityTreeCertificate: &UnicityTreeCertificate{Version: 1, Partition: partitionID},
+			UnicitySeal:            &UnicitySeal{Version: 1, RootChainRoundNumber: rootRound, Hash: []byte{byte(rootRound)}},
+		}
+	}
+	uc1 := newUC(10, 5, []byte{1}, []byte{2}, []byte{3})
+
+	t.Run("invalid input", func(t *testing.T) {
+		tracker := NewUCTracker(nil)
+		_, err := tracker.Update(nil)
+		require.ErrorIs(t, err, ErrUCIsNil)
+		_, err = tracker.Update(&UnicityCertificate{})
+		require.ErrorIs(t, err, ErrInputRecordIsNil)
+		_, err = tracker.Update(&UnicityCertificate{InputRecord: &InputRecord{}})
+		require.ErrorIs(t, err, ErrUnicitySealIsNil)
+		_, err = tracker.Update(&UnicityCertificate{InputRecord: &InputRecord{}, UnicitySeal: &UnicitySeal{}})
+		require.ErrorIs(t, err, ErrUnicityTreeCertificateIsNil)
+	})
+
+	t.Run("classification", func(t *testing.T) {
+		tracker := NewUCTracker(nil)
+		latest, err := tracker.Latest(partitionID, ShardID{})
+		require.NoError(t, err)
+		require.Nil(t, latest)
+
+		class, err := tracker.Update(uc1)
+		require.NoError(t, err)
+		require.Equal(t, UCFirstSeen, class)
+
+		class, err = tracker.Update(uc1)
+		require.NoError(t, err)
+		require.Equal(t, UCDuplicate, class)
+
+		ucRepeat := &UnicityCertificate{
+			Version:                1,
+			InputRecord:            uc1.InputRecord.NewRepeatIR(),
+			UnicityTreeCertificate: uc1.UnicityTreeCertificate,
+			UnicitySeal:            &UnicitySeal{Version: 1, RootChainRoundNumber: 11, Hash: []byte{11}},
+		}
+		class, err = tracker.Update(ucRepeat)
+		require.NoError(t, err)
+		require.Equal(t, UCRepeat, class)
+
+		uc2 := newUC(12, 6, []byte{2}, []byte{4}, []byte{5})
+		class, err = tracker.Update(uc2)
+		require.NoError(t, err)
+		require.Equal(t, UCNewRound, class)
+
+		latest, err = tracker.Latest(partitionID, ShardID{})
+		require.NoError(t, err)
+		require.Equal(t, uc2, latest)
+
+		// UCs of other partitions are tracked independently
+		ucOther := newUC(5, 1, []byte{1}, []byte{2}, []byte{3})
+		ucOther.UnicityTreeCertificate = &UnicityTreeCertificate{Version: 1, Partition: partitionID + 1}
+		class, err = tracker.Update(ucOther)
+		require.NoError(t, err)
+		require.Equal(t, UCFirstSeen, class)
+	})
+
+	t.Run("stale UC", func(t *testing.T) {
+		tracker := NewUCTracker(nil)
+		_, err := tracker.Update(uc1)
+		require.NoError(t, err)
+
+		class, err := tracker.Update(newUC(9, 4, []byte{0}, []byte{1}, []byte{2}))
+		require.ErrorIs(t, err, ErrStaleUC)
+		require.EqualError(t, err, "stale unicity certificate: root round 9 is older than the latest root round 10")
+		require.Zero(t, class)
+
+		latest, err := tracker.Latest(partitionID, ShardID{})
+		require.NoError(t, err)
+		require.Equal(t, uc1, latest)
+	})
+
+	t.Run("equivocating UC", func(t *testing.T) {
+		tracker := NewUCTracker(nil)
+		_, err := tracker.Update(uc1)
+		require.NoError(t, err)
+
+		// same root round, different IR
+		_, err = tracker.Update(newUC(10, 5, []byte{1}, []byte{7}, []byte{3}))
+		require.ErrorIs(t, err, ErrEquivocatingUC)
+		require.EqualError(t, err, "equivocating unicity certificate: different certificates for the same root round 10")
+
+		// same shard round, different IR
+		_, err = tracker.Update(newUC(11, 5, []byte{1}, []byte{7}, []byte{3}))
+		require.ErrorIs(t, err, ErrEquivocatingUC)
+		require.EqualError(t, err, "equivocating unicity certificate: equivocating UC, different input records for same partition round 5")
+
+		// next round doesn't extend the previous state
+		_, err = tracker.Update(newUC(11, 6, []byte{9}, []byte{7}, []byte{8}))
+		require.ErrorIs(t, err, ErrEquivocatingUC)
+		require.EqualError(t, err, "equivocating unicity certificate: new certificate does not extend previous state hash")
+
+		latest, err := tracker.Latest(partitionID, ShardID{})
+		require.NoError(t, err)
+		require.Equal(t, uc1, latest)
+	})
+
+	t.Run("persistence", func(t *testing.T) {
+		store := NewMemoryUCStore()
+		tracker := NewUCTracker(store)
+		_, err := tracker.Update(uc1)
+		require.NoError(t, err)
+
+		// new tracker with the same store continues where the previous one left off
+		tracker = NewUCTracker(store)
+		class, err := tracker.Update(uc1)
+		require.NoError(t, err)
+		require.Equal(t, UCDuplicate, class)
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		expErr := errors.New("store error")
+		tracker := NewUCTracker(&mockUCStore{loadErr: expErr})
+		_, err := tracker.Update(uc1)
+		require.ErrorIs(t, err, expErr)
+
+		tracker = NewUCTracker(&mockUCStore{storeErr: expErr})
+		_, err = tracker.Update(uc1)
+		require.ErrorIs(t, err, expErr)
+		// UC must not be accepted when storing failed
+		latest, err := tracker.Latest(partitionID, ShardID{})
+		require.NoError(t, err)
+		require.Nil(t, latest)
+	})
+}
+
+func TestUCClass_String(t *testing.T) {
+	require.Equal(t, "first seen", UCFirstSeen.String())
+	require.Equal(t, "new round", UCNewRound.String())
+	require.Equal(t, "repeat", UCRepeat.String())
+	require.Equal(t, "duplicate", UCDuplicate.String())
+	require.Equal(t, "UCClass(0)", UCClass(0).String())
+}
+
+type mockUCStore struct {
+	loadErr  error
+	storeErr error
+}
+
+func (s *mockUCStore) LoadLatestUC(PartitionShardID) (*UnicityCertificate, error) {
+	return nil, s.loadErr
+}
+
+func (s *mockUCStore) StoreLatestUC(PartitionShardID, *UnicityCertificate) error {
+	return s.storeErr
+}
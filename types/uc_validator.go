@@ -0,0 +1,150 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var _ UnicityCertificateValidator = (*UCValidator)(nil)
+
+type (
+	// TrustBaseLookup returns the root trust base of the given root chain epoch.
+	TrustBaseLookup func(epoch uint64) (RootTrustBase, error)
+
+	// ShardConfLookup returns the shard configuration (PDR) of the given shard epoch.
+	ShardConfLookup func(partition PartitionID, shard ShardID, epoch uint64) (*PartitionDescriptionRecord, error)
+
+	/*
+		UCValidator is the reference implementation of the UnicityCertificateValidator
+		interface. It verifies the UC against the trust base of the epoch the UC was
+		issued in and against the shard configuration of the shard epoch of the UC.
+	*/
+	UCValidator struct {
+		trustBase     TrustBaseLookup
+		shardConf     ShardConfLookup
+		hashAlgorithm crypto.Hash
+		minRootRound  uint64
+		maxAge        time.Duration
+		now           func() time.Time
+		verifyOpts    []VerifyOption
+	}
+
+	UCValidatorOption func(v *UCValidator)
+)
+
+/*
+NewUCValidator returns UC validator which uses "trustBase" to acquire the root trust
+base of the UC's epoch and "shardConf" to acquire the shard configuration of the
+UC's shard epoch.
+*/
+func NewUCValidator(trustBase TrustBaseLookup, shardConf ShardConfLookup, algorithm crypto.Hash, opts ...UCValidatorOption) (*UCValidator, error) {
+	if trustBase == nil {
+		return nil, errors.New("trust base lookup is nil")
+	}
+	if shardConf == nil {
+		return nil, errors.New("shard configuration lookup is nil")
+	}
+	v := &UCValidator{
+		trustBase:     trustBase,
+		shardConf:     shardConf,
+		hashAlgorithm: algorithm,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+/*
+WithMinRootRound makes the validator to reject UCs issued before given root round.
+*/
+func WithMinRootRound(round uint64) UCValidatorOption {
+	return func(v *UCValidator) {
+		v.minRootRound = round
+	}
+}
+
+/*
+WithMaxAge makes the validator to reject UCs whose unicity seal timestamp is
+older than "age" compared to the current wall clock time.
+*/
+func WithMaxAge(age time.Duration) UCValidatorOption {
+	return func(v *UCValidator) {
+		v.maxAge = age
+	}
+}
+
+/*
+WithVerifyOptions sets the options used to verify the UC, ie WithAcceptedNetworks.
+*/
+func WithVerifyOptions(opts ...VerifyOption) UCValidatorOption {
+	return func(v *UCValidator) {
+		v.verifyOpts = append(v.verifyOpts, opts...)
+	}
+}
+
+/*
+Validate checks that the UC is valid, the shard configuration hash in the UC
+matches the hash of the PDR of the UC's shard and epoch and that the UC is
+signed by the quorum of the root validators of the UC's epoch. The UC must be
+issued in the network of the PDR and of the trust base.
+
+When "shardConfHash" is not nil the UC's shard configuration hash must also
+match it.
+*/
+func (v *UCValidator) Validate(uc *UnicityCertificate, shardConfHash []byte) error {
+	if uc == nil {
+		return ErrUnicityCertificateIsNil
+	}
+	if uc.UnicityTreeCertificate == nil {
+		return ErrUnicityTreeCertificateIsNil
+	}
+	if uc.InputRecord == nil {
+		return ErrInputRecordIsNil
+	}
+	if uc.UnicitySeal == nil {
+		return ErrUnicitySealIsNil
+	}
+	partitionID := uc.GetPartitionID()
+	if err := uc.IsValid(partitionID, shardConfHash); err != nil {
+		return fmt.Errorf("invalid unicity certificate: %w", err)
+	}
+
+	pdr, err := v.shardConf(partitionID, uc.GetShardID(), uc.InputRecord.Epoch)
+	if err != nil {
+		return fmt.Errorf("acquiring shard configuration of partition %s shard %q epoch %d: %w", partitionID, uc.GetShardID(), uc.InputRecord.Epoch, err)
+	}
+	pdrHash, err := pdr.Hash(v.hashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("hashing shard configuration: %w", err)
+	}
+	if !bytes.Equal(pdrHash, uc.ShardConfHash) {
+		return fmt.Errorf("shard configuration hash %X does not match the hash of the shard configuration %X", uc.ShardConfHash, pdrHash)
+	}
+	if pdr.NetworkID != uc.UnicitySeal.NetworkID {
+		return fmt.Errorf("%w: shard configuration network %d does not match unicity seal network %d", ErrNetworkMismatch, pdr.NetworkID, uc.UnicitySeal.NetworkID)
+	}
+
+	tb, err := v.trustBase(uc.UnicitySeal.Epoch)
+	if err != nil {
+		return fmt.Errorf("acquiring trust base of epoch %d: %w", uc.UnicitySeal.Epoch, err)
+	}
+	if err := uc.Verify(tb, v.hashAlgorithm, partitionID, pdrHash, v.verifyOpts...); err != nil {
+		return err
+	}
+
+	if rr := uc.GetRootRoundNumber(); rr < v.minRootRound {
+		return fmt.Errorf("UC is from root round %d, expected at least %d", rr, v.minRootRound)
+	}
+	if v.maxAge > 0 {
+		ucTime := time.Unix(int64(uc.UnicitySeal.Timestamp), 0) // #nosec G115 timestamp is seconds, it will not exceed int64 max value
+		if age := v.now().Sub(ucTime); age > v.maxAge {
+			return fmt.Errorf("UC is too old: issued %s ago, max allowed age is %s", age.Truncate(time.Second), v.maxAge)
+		}
+	}
+	return nil
+}
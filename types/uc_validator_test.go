@@ -0,0 +1,153 @@
+package types
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testsig "github.com/alphabill-org/alphabill-go-base/testutils/sig"
+)
+
+func TestUCValidator(t *testing.T) {
+	signer, verifier := testsig.CreateSignerAndVerifier(t)
+	tb := NewTrustBase(t, verifier)
+	pdr := &PartitionDescriptionRecord{
+		Version:     1,
+		NetworkID:   NetworkMainNet,
+		PartitionID: 0x01020304,
+		TypeIDLen:   8,
+		UnitIDLen:   256,
+		T2Timeout:   2500 * time.Millisecond,
+	}
+	ir := &InputRecord{
+		Version:      1,
+		RoundNumber:  5,
+		PreviousHash: []byte{0, 0, 1},
+		Hash:         []byte{0, 0, 2},
+		BlockHash:    []byte{0, 0, 3},
+		SummaryValue: []byte{0, 0, 4},
+		Timestamp:    NewTimestamp(),
+	}
+	uc := createUnicityCertificate(t, "test", signer, ir, make([]byte, 32), pdr)
+
+	tbLookup := func(epoch uint64) (RootTrustBase, error) {
+		if epoch != 0 {
+			return nil, errors.New("unknown epoch")
+		}
+		return tb, nil
+	}
+	pdrLookup := func(partition PartitionID, shard ShardID, epoch uint64) (*PartitionDescriptionRecord, error) {
+		if partition != pdr.PartitionID || epoch != 0 {
+			return nil, errors.New("unknown shard")
+		}
+		return pdr, nil
+	}
+
+	t.Run("invalid constructor arguments", func(t *testing.T) {
+		v, err := NewUCValidator(nil, pdrLookup, crypto.SHA256)
+		require.EqualError(t, err, "trust base lookup is nil")
+		require.Nil(t, v)
+
+		v, err = NewUCValidator(tbLookup, nil, crypto.SHA256)
+		require.EqualError(t, err, "shard configuration lookup is nil")
+		require.Nil(t, v)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		v, err := NewUCValidator(tbLookup, pdrLookup, crypto.SHA256)
+		require.NoError(t, err)
+		require.NoError(t, v.Validate(uc, nil))
+		require.NoError(t, v.Validate(uc, uc.ShardConfHash))
+	})
+
+	t.Run("invalid UC", func(t *testing.T) {
+		v, err := NewUCValidator(tbLookup, pdrLookup, crypto.SHA256)
+		require.NoError(t, err)
+		require.ErrorIs(t, v.Validate(nil, nil), ErrUnicityCertificateIsNil)
+		require.ErrorIs(t, v.Validate(&UnicityCertificate{Version: 1}, nil), ErrUnicityTreeCertificateIsNil)
+
+		ucCopy := *uc
+		ucCopy.TRHash = nil
+		require.EqualError(t, v.Validate(&ucCopy, nil), "invalid unicity certificate: invalid TRHash: expected 32 bytes, got 0 bytes")
+
+		require.EqualError(t, v.Validate(uc, []byte{1, 2, 3}), fmt.Sprintf("invalid unicity certificate: invalid shard configuration hash: expected 010203, got %X", uc.ShardConfHash))
+	})
+
+	t.Run("shard configuration lookup fails", func(t *testing.T) {
+		v, err := NewUCValidator(tbLookup, func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) {
+			return nil, errors.New("not found")
+		}, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, v.Validate(uc, nil), `acquiring shard configuration of partition 01020304 shard "" epoch 0: not found`)
+	})
+
+	t.Run("shard configuration hash mismatch", func(t *testing.T) {
+		otherPDR := *pdr
+		otherPDR.T2Timeout = 3 * time.Second
+		v, err := NewUCValidator(tbLookup, func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) {
+			return &otherPDR, nil
+		}, crypto.SHA256)
+		require.NoError(t, err)
+		require.ErrorContains(t, v.Validate(uc, nil), "does not match the hash of the shard configuration")
+	})
+
+	t.Run("network mismatch", func(t *testing.T) {
+		// shard configuration of another network
+		otherPDR := *pdr
+		otherPDR.NetworkID = NetworkTestNet
+		otherUC := createUnicityCertificate(t, "test", signer, ir, make([]byte, 32), &otherPDR)
+		v, err := NewUCValidator(tbLookup, func(PartitionID, ShardID, uint64) (*PartitionDescriptionRecord, error) {
+			return &otherPDR, nil
+		}, crypto.SHA256)
+		require.NoError(t, err)
+		err = v.Validate(otherUC, nil)
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "network mismatch: shard configuration network 2 does not match unicity seal network 1")
+
+		// network of the UC is not accepted
+		v, err = NewUCValidator(tbLookup, pdrLookup, crypto.SHA256, WithVerifyOptions(WithAcceptedNetworks(NetworkTestNet)))
+		require.NoError(t, err)
+		err = v.Validate(uc, nil)
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "verifying unicity seal: network mismatch: network 1 is not accepted, accepted networks are [2]")
+	})
+
+	t.Run("trust base lookup fails", func(t *testing.T) {
+		v, err := NewUCValidator(func(uint64) (RootTrustBase, error) {
+			return nil, errors.New("not found")
+		}, pdrLookup, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, v.Validate(uc, nil), "acquiring trust base of epoch 0: not found")
+	})
+
+	t.Run("signed by unknown root node", func(t *testing.T) {
+		_, otherVerifier := testsig.CreateSignerAndVerifier(t)
+		otherTB := NewTrustBase(t, otherVerifier)
+		v, err := NewUCValidator(func(uint64) (RootTrustBase, error) { return otherTB, nil }, pdrLookup, crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualError(t, v.Validate(uc, nil), "verifying unicity seal: verifying signatures: quorum not reached, signed_votes=0 quorum_threshold=1")
+	})
+
+	t.Run("min root round", func(t *testing.T) {
+		v, err := NewUCValidator(tbLookup, pdrLookup, crypto.SHA256, WithMinRootRound(1))
+		require.NoError(t, err)
+		require.NoError(t, v.Validate(uc, nil))
+
+		v, err = NewUCValidator(tbLookup, pdrLookup, crypto.SHA256, WithMinRootRound(2))
+		require.NoError(t, err)
+		require.EqualError(t, v.Validate(uc, nil), "UC is from root round 1, expected at least 2")
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		v, err := NewUCValidator(tbLookup, pdrLookup, crypto.SHA256, WithMaxAge(time.Minute))
+		require.NoError(t, err)
+		require.NoError(t, v.Validate(uc, nil))
+
+		v.now = func() time.Time { return time.Now().Add(time.Hour) }
+		require.ErrorContains(t, v.Validate(uc, nil), "UC is too old: issued 1h0m")
+	})
+}
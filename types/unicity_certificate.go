@@ -51,7 +51,7 @@ func (x *UnicityCertificate) IsValid(partitionID PartitionID, shardConfHash []by
 	return nil
 }
 
-func (x *UnicityCertificate) Verify(tb RootTrustBase, algorithm crypto.Hash, partitionID PartitionID, shardConfHash []byte) error {
+func (x *UnicityCertificate) Verify(tb RootTrustBase, algorithm crypto.Hash, partitionID PartitionID, shardConfHash []byte, opts ...VerifyOption) error {
 	if err := x.IsValid(partitionID, shardConfHash); err != nil {
 		return fmt.Errorf("invalid unicity certificate: %w", err)
 	}
@@ -69,7 +69,7 @@ func (x *UnicityCertificate) Verify(tb RootTrustBase, algorithm crypto.Hash, par
 		return fmt.Errorf("unicity seal hash %X does not match with the root hash of the unicity tree %X", rootHash, unicityTreeRoot)
 	}
 
-	if err := x.UnicitySeal.Verify(tb); err != nil {
+	if err := x.UnicitySeal.Verify(tb, opts...); err != nil {
 		return fmt.Errorf("verifying unicity seal: %w", err)
 	}
 	return nil
@@ -149,43 +149,53 @@ func (x *UnicityCertificate) GetShardID() ShardID {
 // NB! order is important, also it is assumed that validity of both UCs is checked before
 // The algorithm is based on Yellowpaper: "Algorithm 6 Checking two UC-s for equivocation"
 func CheckNonEquivocatingCertificates(prevUC, newUC *UnicityCertificate) error {
+	_, err := checkEquivocation(prevUC, newUC)
+	return err
+}
+
+/*
+checkEquivocation implements CheckNonEquivocatingCertificates, in case the
+certificates are equivocating it also returns the rule which was violated
+(rule is zero when error is not caused by equivocation).
+*/
+func checkEquivocation(prevUC, newUC *UnicityCertificate) (EquivocationRule, error) {
 	if newUC == nil {
-		return ErrUCIsNil
+		return 0, ErrUCIsNil
 	}
 	if prevUC == nil {
-		return ErrLastUCIsNil
+		return 0, ErrLastUCIsNil
 	}
 
 	// verify order, check both partition round and root round
 	if newUC.GetRootRoundNumber() < prevUC.GetRootRoundNumber() {
-		return fmt.Errorf("new certificate is from older root round %v than previous certificate %v",
+		return 0, fmt.Errorf("new certificate is from older root round %v than previous certificate %v",
 			newUC.UnicitySeal.RootChainRoundNumber, prevUC.UnicitySeal.RootChainRoundNumber)
 	}
 	if newUC.GetRoundNumber() < prevUC.GetRoundNumber() {
-		return fmt.Errorf("new certificate is from older partition round %v than previous certificate %v",
+		return EquivocationRoundRegression, fmt.Errorf("new certificate is from older partition round %v than previous certificate %v",
 			newUC.InputRecord.RoundNumber, prevUC.InputRecord.RoundNumber)
 	}
 	// 1. uc.IR.n = uc′.IR.n - if the partition round number is the same then input records must also match
 	if newUC.GetRoundNumber() == prevUC.GetRoundNumber() {
 		newIrBytes, err := newUC.InputRecord.Bytes()
 		if err != nil {
-			return fmt.Errorf("new certificate input record bytes: %w", err)
+			return 0, fmt.Errorf("new certificate input record bytes: %w", err)
 		}
 		prevIrBytes, err := prevUC.InputRecord.Bytes()
 		if err != nil {
-			return fmt.Errorf("previous certificate input record bytes: %w", err)
+			return 0, fmt.Errorf("previous certificate input record bytes: %w", err)
 		}
 		if !bytes.Equal(newIrBytes, prevIrBytes) {
-			return fmt.Errorf("equivocating UC, different input records for same partition round %v", newUC.GetRoundNumber())
+			return EquivocationDifferentIR, fmt.Errorf("equivocating UC, different input records for same partition round %v", newUC.GetRoundNumber())
 		}
 		// it's a Repeat UC
-		return nil
+		return 0, nil
 	}
 	// 2. not a repeat UC, then it must extend from previous state if certificates are from consecutive rounds,
 	// if it is not from consecutive rounds then it is simply not possible to make any conclusions
 	if newUC.GetRoundNumber() == prevUC.GetRoundNumber()+1 &&
 		!bytes.Equal(newUC.InputRecord.PreviousHash, prevUC.InputRecord.Hash) {
-		return fmt.Errorf("new certificate does not extend previous state hash")
+		return EquivocationBrokenStateChain, fmt.Errorf("new certificate does not extend previous state hash")
 	}
 	// 5. uc.IR.h′ = uc.IR.h and uc.IR.h = uc.IR.h' -> extends last known state and new state does not change,
 	// then new block must be empty
@@ -193,7 +203,7 @@ func CheckNonEquivocatingCertificates(prevUC, newUC *UnicityCertificate) error {
 		bytes.Equal(newUC.InputRecord.Hash, newUC.InputRecord.PreviousHash) {
 		// then new block must not be empty
 		if len(newUC.InputRecord.BlockHash) != 0 {
-			return fmt.Errorf("new UC extends state hash, new state hash does not change, but block is not empty")
+			return EquivocationNonEmptyBlock, fmt.Errorf("new UC extends state hash, new state hash does not change, but block is not empty")
 		}
 	}
 	// 6. uc.IR.h′ = uc'.IR.h and uc.IR.h = uc'.IR.h -> previous state hash is equal and new state is not equal,
@@ -202,14 +212,14 @@ func CheckNonEquivocatingCertificates(prevUC, newUC *UnicityCertificate) error {
 		!bytes.Equal(newUC.InputRecord.Hash, newUC.InputRecord.PreviousHash) {
 		// then new block must not be empty
 		if len(newUC.InputRecord.BlockHash) == 0 {
-			return fmt.Errorf("new UC extends state hash, new state hash changes, but block is empty")
+			return EquivocationEmptyBlock, fmt.Errorf("new UC extends state hash, new state hash changes, but block is empty")
 		}
 	}
 	// 7. non-empty block hash can only repeat in repeat UC
 	if len(newUC.InputRecord.BlockHash) != 0 && bytes.Equal(newUC.InputRecord.BlockHash, prevUC.InputRecord.BlockHash) {
-		return fmt.Errorf("new certificate repeats previous block hash")
+		return EquivocationRepeatedBlockHash, fmt.Errorf("new certificate repeats previous block hash")
 	}
-	return nil
+	return 0, nil
 }
 
 func (x *UnicityCertificate) IsSuccessor(prevUC *UnicityCertificate) bool {
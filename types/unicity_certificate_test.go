@@ -50,6 +50,7 @@ func TestUnicityCertificate_IsValid(t *testing.T) {
 	validUC := func(t *testing.T) *UnicityCertificate {
 		seal := &UnicitySeal{
 			Version:              1,
+			NetworkID:            NetworkMainNet,
 			RootChainRoundNumber: 1,
 			Timestamp:            NewTimestamp(),
 			PreviousHash:         test.RandomBytes(32),
@@ -191,6 +192,7 @@ func TestUnicityCertificate_Verify(t *testing.T) {
 
 		seal := &UnicitySeal{
 			Version:              1,
+			NetworkID:            NetworkMainNet,
 			RootChainRoundNumber: 1,
 			Timestamp:            NewTimestamp(),
 			PreviousHash:         test.RandomBytes(32),
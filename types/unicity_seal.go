@@ -106,13 +106,21 @@ func (x *UnicitySeal) Sign(id string, signer crypto.Signer) error {
 	return nil
 }
 
-func (x *UnicitySeal) Verify(tb RootTrustBase) error {
+/*
+Verify checks that the seal is valid, is issued in the network of the trust base
+(see WithAcceptedNetworks for exceptions) and is signed by the quorum of the
+trust base.
+*/
+func (x *UnicitySeal) Verify(tb RootTrustBase, opts ...VerifyOption) error {
 	if tb == nil {
 		return ErrRootValidatorInfoMissing
 	}
 	if err := x.IsValid(); err != nil {
 		return fmt.Errorf("invalid unicity seal: %w", err)
 	}
+	if err := newVerifyOptions(opts).checkNetwork(x.NetworkID, tb); err != nil {
+		return err
+	}
 	bs, err := x.SigBytes()
 	if err != nil {
 		return fmt.Errorf("failed to marshal unicity seal: %w", err)
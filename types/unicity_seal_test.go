@@ -87,7 +87,7 @@ func TestUnicitySeal_Verify(t *testing.T) {
 	createUS := func() UnicitySeal {
 		return UnicitySeal{
 			Version:              1,
-			NetworkID:            2,
+			NetworkID:            NetworkMainNet,
 			RootChainRoundNumber: 3,
 			Epoch:                4,
 			Timestamp:            NewTimestamp(),
@@ -137,6 +137,21 @@ func TestUnicitySeal_Verify(t *testing.T) {
 		require.EqualError(t, err, "verifying signatures: quorum not reached, signed_votes=1 quorum_threshold=2")
 	})
 
+	t.Run("network mismatch", func(t *testing.T) {
+		seal := createUS()
+		seal.NetworkID = NetworkTestNet
+		require.NoError(t, seal.Sign("test", signer))
+		err := seal.Verify(trustBase)
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "network mismatch: expected network 1 (trust base), got 2")
+
+		// verifier accepting multiple networks
+		require.NoError(t, seal.Verify(trustBase, WithAcceptedNetworks(NetworkMainNet, NetworkTestNet)))
+		err = seal.Verify(trustBase, WithAcceptedNetworks(NetworkMainNet, NetworkLocal))
+		require.ErrorIs(t, err, ErrNetworkMismatch)
+		require.EqualError(t, err, "network mismatch: network 2 is not accepted, accepted networks are [1 3]")
+	})
+
 	t.Run("OK", func(t *testing.T) {
 		seal := createUS()
 		require.NoError(t, seal.Sign("test", signer))
@@ -188,6 +203,7 @@ func TestSeal_AddToHasher(t *testing.T) {
 func TestUnicitySeal_cbor(t *testing.T) {
 	signer, verifier := testsig.CreateSignerAndVerifier(t)
 	seal := &UnicitySeal{
+		NetworkID:            NetworkMainNet,
 		RootChainRoundNumber: 1,
 		Timestamp:            NewTimestamp(),
 		PreviousHash:         nil,
@@ -113,6 +113,7 @@ func createUnicityCertificate(
 
 	unicitySeal := &UnicitySeal{
 		Version:              1,
+		NetworkID:            NetworkMainNet,
 		RootChainRoundNumber: 1,
 		Timestamp:            NewTimestamp(),
 		PreviousHash:         make([]byte, 32),
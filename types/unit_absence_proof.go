@@ -0,0 +1,166 @@
+package types
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var ErrUnitAbsenceProofIsNil = errors.New("unit absence proof is nil")
+
+/*
+UnitAbsenceProof proves that the unit does not exist in the state tree, ie the
+unit was deleted or the unit ID is free.
+
+The state tree is a binary search tree keyed by the unit ID. Search of the
+absent unit ID ends in the node which doesn't have child in the direction of the
+unit ID. The proof contains the data of that node (the "neighbour" of the absent
+unit) and the StateTreeCert of the node where the child in the direction of the
+absent unit is empty. As the unit IDs of the nodes are part of the node hashes
+the search path of the unit ID is fixed by the state root hash.
+
+Proof of absence can't be created for the empty state tree.
+*/
+type UnitAbsenceProof struct {
+	_                  struct{}       `cbor:",toarray"`
+	Version            ABVersion      `json:"version"`
+	UnitID             UnitID         `json:"unitId"`           // ID of the absent unit
+	NodeUnitID         UnitID         `json:"nodeUnitId"`       // ID of the unit where the search of the UnitID ends
+	NodeLogsHash       hex.Bytes      `json:"nodeLogsHash"`     // log root of the NodeUnitID unit
+	NodeValue          uint64         `json:"nodeValue,string"` // data summary of the NodeUnitID unit
+	StateTreeCert      *StateTreeCert `json:"stateTreeCert"`    // state tree cert of the NodeUnitID unit
+	UnicityCertificate TaggedCBOR     `json:"unicityCert"`
+}
+
+// GetUC returns the unicity certificate of the proof.
+func (p *UnitAbsenceProof) GetUC() (*UnicityCertificate, error) {
+	if p == nil {
+		return nil, ErrUnitAbsenceProofIsNil
+	}
+	if p.UnicityCertificate == nil {
+		return nil, ErrUnicityCertificateIsNil
+	}
+	uc := &UnicityCertificate{}
+	if err := Cbor.Unmarshal(p.UnicityCertificate, uc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal unicity certificate: %w", err)
+	}
+	return uc, nil
+}
+
+/*
+Verify checks that the unit UnitID does not exist in the state certified by the
+UC of the proof, ie:
+  - the search path of the UnitID leads to the NodeUnitID;
+  - the child of the NodeUnitID in the direction of the UnitID is empty;
+  - state root hash and summary value calculated from the proof match the
+    input record of the UC.
+*/
+func (p *UnitAbsenceProof) Verify(algorithm crypto.Hash, ucv UnicityCertificateValidator, shardConfHash []byte) error {
+	if err := p.IsValid(); err != nil {
+		return fmt.Errorf("invalid unit absence proof: %w", err)
+	}
+	if ucv == nil {
+		return errors.New("unicity certificate validator is nil")
+	}
+
+	uc, err := p.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate: %w", err)
+	}
+	if err := ucv.Validate(uc, shardConfHash); err != nil {
+		return fmt.Errorf("invalid unicity certificate: %w", err)
+	}
+
+	if err := p.verifySearchPath(); err != nil {
+		return err
+	}
+
+	stateRootHash, summary, err := p.CalculateStateTreeOutput(algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to calculate state tree output: %w", err)
+	}
+	return checkStateTreeOutput(uc.InputRecord, stateRootHash, summary)
+}
+
+/*
+verifySearchPath checks that the search of the UnitID ends in the empty child
+of the NodeUnitID, ie the UnitID is on the same side of every node of the path
+as the NodeUnitID.
+*/
+func (p *UnitAbsenceProof) verifySearchPath() error {
+	sc := p.StateTreeCert
+	switch p.UnitID.Compare(p.NodeUnitID) {
+	case 0:
+		return fmt.Errorf("unit %s exists in the state tree", p.UnitID)
+	case -1:
+		if len(sc.LeftSummaryHash) != 0 || sc.LeftSummaryValue != 0 {
+			return fmt.Errorf("left subtree of the unit %s is not empty", p.NodeUnitID)
+		}
+	default:
+		if len(sc.RightSummaryHash) != 0 || sc.RightSummaryValue != 0 {
+			return fmt.Errorf("right subtree of the unit %s is not empty", p.NodeUnitID)
+		}
+	}
+	for _, item := range sc.Path {
+		c := p.UnitID.Compare(item.UnitID)
+		if c == 0 {
+			return fmt.Errorf("unit %s exists in the state tree", p.UnitID)
+		}
+		if c != p.NodeUnitID.Compare(item.UnitID) {
+			return fmt.Errorf("unit %s is not in the search path of the unit %s", p.NodeUnitID, p.UnitID)
+		}
+	}
+	return nil
+}
+
+// CalculateStateTreeOutput returns the state root hash and summary value of the state tree.
+func (p *UnitAbsenceProof) CalculateStateTreeOutput(algorithm crypto.Hash) ([]byte, uint64, error) {
+	return p.StateTreeCert.calculateOutput(algorithm, p.NodeUnitID, p.NodeLogsHash, p.NodeValue)
+}
+
+func (p *UnitAbsenceProof) IsValid() error {
+	if p == nil {
+		return ErrUnitAbsenceProofIsNil
+	}
+	if p.Version != 1 {
+		return ErrInvalidVersion(p)
+	}
+	if len(p.UnitID) == 0 {
+		return errors.New("unit ID is unassigned")
+	}
+	if len(p.NodeUnitID) == 0 {
+		return errors.New("node unit ID is unassigned")
+	}
+	if p.StateTreeCert == nil {
+		return errors.New("state tree cert is nil")
+	}
+	if p.UnicityCertificate == nil {
+		return errors.New("unicity certificate is nil")
+	}
+	return nil
+}
+
+func (p *UnitAbsenceProof) GetVersion() ABVersion {
+	if p != nil && p.Version > 0 {
+		return p.Version
+	}
+	return 1
+}
+
+func (p *UnitAbsenceProof) MarshalCBOR() ([]byte, error) {
+	type alias UnitAbsenceProof
+	if p.Version == 0 {
+		p.Version = p.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(UnitAbsenceProofTag, (*alias)(p))
+}
+
+func (p *UnitAbsenceProof) UnmarshalCBOR(data []byte) error {
+	type alias UnitAbsenceProof
+	if err := Cbor.UnmarshalTaggedValue(UnitAbsenceProofTag, data, (*alias)(p)); err != nil {
+		return err
+	}
+	return EnsureVersion(p, p.Version, 1)
+}
@@ -0,0 +1,130 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+func TestUnitAbsenceProof(t *testing.T) {
+	// state tree of three units: B is the root, A is the left and C is the right child
+	idA, idB, idC := UnitID{2}, UnitID{4}, UnitID{6}
+	logA, logB, logC := []byte{0xA}, []byte{0xB}, []byte{0xC}
+	hashA, err := computeHash(crypto.SHA256, idA, logA, 1, nil, 0, nil, 0)
+	require.NoError(t, err)
+	hashC, err := computeHash(crypto.SHA256, idC, logC, 3, nil, 0, nil, 0)
+	require.NoError(t, err)
+	rootHash, err := computeHash(crypto.SHA256, idB, logB, 6, hashA, 1, hashC, 3)
+	require.NoError(t, err)
+	uc, err := (&UnicityCertificate{
+		Version:     1,
+		InputRecord: &InputRecord{Version: 1, Hash: rootHash, SummaryValue: util.Uint64ToBytes(6)},
+	}).MarshalCBOR()
+	require.NoError(t, err)
+
+	// search of the unit ID 5 ends in the empty left child of C
+	newProof := func() *UnitAbsenceProof {
+		return &UnitAbsenceProof{
+			Version:      1,
+			UnitID:       UnitID{5},
+			NodeUnitID:   idC,
+			NodeLogsHash: logC,
+			NodeValue:    3,
+			StateTreeCert: &StateTreeCert{
+				Path: []*StateTreePathItem{{UnitID: idB, LogsHash: logB, Value: 2, SiblingSummaryHash: hashA, SiblingSummaryValue: 1}},
+			},
+			UnicityCertificate: uc,
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		proof := newProof()
+		require.NoError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil))
+
+		data, err := Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &UnitAbsenceProof{}
+		require.NoError(t, Cbor.Unmarshal(data, proof2))
+		require.NoError(t, proof2.Verify(crypto.SHA256, &alwaysValid{}, nil))
+
+		// C has no children so the same proof proves absence of the unit 7
+		proof.UnitID = UnitID{7}
+		require.NoError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil))
+	})
+
+	t.Run("unit exists", func(t *testing.T) {
+		proof := newProof()
+		proof.UnitID = idC
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "unit 06 exists in the state tree")
+
+		proof.UnitID = idB
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "unit 04 exists in the state tree")
+	})
+
+	t.Run("unit is not in the search path", func(t *testing.T) {
+		proof := newProof()
+		proof.UnitID = UnitID{3}
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "unit 06 is not in the search path of the unit 03")
+	})
+
+	t.Run("subtree is not empty", func(t *testing.T) {
+		// B has left child so the search of the unit 3 doesn't end in B
+		proof := &UnitAbsenceProof{
+			Version:      1,
+			UnitID:       UnitID{3},
+			NodeUnitID:   idB,
+			NodeLogsHash: logB,
+			NodeValue:    2,
+			StateTreeCert: &StateTreeCert{
+				LeftSummaryHash:   hashA,
+				LeftSummaryValue:  1,
+				RightSummaryHash:  hashC,
+				RightSummaryValue: 3,
+			},
+			UnicityCertificate: uc,
+		}
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "left subtree of the unit 04 is not empty")
+		proof.UnitID = UnitID{5}
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "right subtree of the unit 04 is not empty")
+
+		// hiding the child changes the state root hash
+		proof.StateTreeCert.RightSummaryHash, proof.StateTreeCert.RightSummaryValue = nil, 0
+		require.ErrorContains(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "invalid summary value")
+		proof.NodeValue = 5
+		require.ErrorContains(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "invalid state root hash")
+	})
+
+	t.Run("invalid UC", func(t *testing.T) {
+		require.EqualError(t, newProof().Verify(crypto.SHA256, &alwaysInvalid{}, nil), "invalid unicity certificate: invalid uc")
+		require.EqualError(t, newProof().Verify(crypto.SHA256, nil, nil), "unicity certificate validator is nil")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var proof *UnitAbsenceProof
+		require.ErrorIs(t, proof.IsValid(), ErrUnitAbsenceProofIsNil)
+
+		proof = newProof()
+		proof.Version = 2
+		require.ErrorContains(t, proof.IsValid(), "invalid version")
+
+		proof = newProof()
+		proof.UnitID = nil
+		require.EqualError(t, proof.IsValid(), "unit ID is unassigned")
+
+		proof = newProof()
+		proof.NodeUnitID = nil
+		require.EqualError(t, proof.IsValid(), "node unit ID is unassigned")
+
+		proof = newProof()
+		proof.StateTreeCert = nil
+		require.EqualError(t, proof.IsValid(), "state tree cert is nil")
+
+		proof = newProof()
+		proof.UnicityCertificate = nil
+		require.EqualError(t, proof.IsValid(), "unicity certificate is nil")
+		require.EqualError(t, proof.Verify(crypto.SHA256, &alwaysValid{}, nil), "invalid unit absence proof: unicity certificate is nil")
+	})
+}
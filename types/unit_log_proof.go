@@ -0,0 +1,190 @@
+package types
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
+	"github.com/alphabill-org/alphabill-go-base/types/hex"
+)
+
+var ErrUnitLogProofIsNil = errors.New("unit log proof is nil")
+
+type (
+	/*
+		UnitLogProof proves the full sequence of the states of the unit created
+		in the round, ie all the entries of the unit's log. While UnitStateProof
+		proves only the latest log entry using the Merkle path of the log tree
+		the UnitLogProof lists all the entries so the log root is reconstructed
+		from the entries.
+	*/
+	UnitLogProof struct {
+		_                  struct{}        `cbor:",toarray"`
+		Version            ABVersion       `json:"version"`
+		UnitID             UnitID          `json:"unitId"`
+		UnitValue          uint64          `json:"unitValue,string"` // data summary of the latest state of the unit
+		UnitLedgerHash     hex.Bytes       `json:"unitLedgerHash"`   // head of the unit's ledger before the first entry
+		Entries            []*UnitLogEntry `json:"entries"`
+		StateTreeCert      *StateTreeCert  `json:"stateTreeCert"`
+		UnicityCertificate TaggedCBOR      `json:"unicityCert"`
+	}
+
+	UnitLogEntry struct {
+		_                     struct{}  `cbor:",toarray"`
+		TransactionRecordHash hex.Bytes `json:"txrHash"`       // nil when the state was not created by a transaction
+		UnitStateHash         hex.Bytes `json:"unitStateHash"` // hash of the state created by the transaction
+	}
+
+	// logEntryHash is the leaf of the unit log tree.
+	logEntryHash []byte
+)
+
+// GetUC returns the unicity certificate of the proof.
+func (p *UnitLogProof) GetUC() (*UnicityCertificate, error) {
+	if p == nil {
+		return nil, ErrUnitLogProofIsNil
+	}
+	if p.UnicityCertificate == nil {
+		return nil, ErrUnicityCertificateIsNil
+	}
+	uc := &UnicityCertificate{}
+	if err := Cbor.Unmarshal(p.UnicityCertificate, uc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal unicity certificate: %w", err)
+	}
+	return uc, nil
+}
+
+/*
+Verify checks that the "states" are the states of the unit created in the round
+certified by the UC of the proof, in the order of the log entries. The states
+must be given for all the entries of the proof.
+*/
+func (p *UnitLogProof) Verify(algorithm crypto.Hash, states []*UnitState, ucv UnicityCertificateValidator, shardConfHash []byte) error {
+	if err := p.IsValid(); err != nil {
+		return fmt.Errorf("invalid unit log proof: %w", err)
+	}
+	if ucv == nil {
+		return errors.New("unicity certificate validator is nil")
+	}
+	if len(states) != len(p.Entries) {
+		return fmt.Errorf("expected %d unit states, got %d", len(p.Entries), len(states))
+	}
+
+	uc, err := p.GetUC()
+	if err != nil {
+		return fmt.Errorf("failed to get unicity certificate: %w", err)
+	}
+	if err := ucv.Validate(uc, shardConfHash); err != nil {
+		return fmt.Errorf("invalid unicity certificate: %w", err)
+	}
+
+	for i, state := range states {
+		if state == nil {
+			return fmt.Errorf("unit state %d is nil", i)
+		}
+		stateHash, err := state.Hash(algorithm)
+		if err != nil {
+			return fmt.Errorf("failed to calculate hash of unit state %d: %w", i, err)
+		}
+		if !bytes.Equal(p.Entries[i].UnitStateHash, stateHash) {
+			return fmt.Errorf("unit state %d hash does not match unit state hash of the log entry", i)
+		}
+	}
+
+	stateRootHash, summary, err := p.CalculateStateTreeOutput(algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to calculate state tree output: %w", err)
+	}
+	return checkStateTreeOutput(uc.InputRecord, stateRootHash, summary)
+}
+
+/*
+LogRoot returns the root hash of the unit log tree reconstructed from the log
+entries of the proof.
+*/
+func (p *UnitLogProof) LogRoot(algorithm crypto.Hash) ([]byte, error) {
+	hashes := make([]logEntryHash, len(p.Entries))
+	prevHash := []byte(p.UnitLedgerHash)
+	for i, e := range p.Entries {
+		h, err := UnitLedgerHash(algorithm, prevHash, e.TransactionRecordHash, e.UnitStateHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate hash of log entry %d: %w", i, err)
+		}
+		hashes[i] = h
+		prevHash = h
+	}
+	tree, err := mt.New(algorithm, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return tree.GetRootHash(), nil
+}
+
+// CalculateStateTreeOutput returns the state root hash and summary value of the state tree.
+func (p *UnitLogProof) CalculateStateTreeOutput(algorithm crypto.Hash) ([]byte, uint64, error) {
+	logRoot, err := p.LogRoot(algorithm)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to calculate log root: %w", err)
+	}
+	return p.StateTreeCert.calculateOutput(algorithm, p.UnitID, logRoot, p.UnitValue)
+}
+
+func (p *UnitLogProof) IsValid() error {
+	if p == nil {
+		return ErrUnitLogProofIsNil
+	}
+	if p.Version != 1 {
+		return ErrInvalidVersion(p)
+	}
+	if len(p.UnitID) == 0 {
+		return errors.New("unit ID is unassigned")
+	}
+	if len(p.Entries) == 0 {
+		return errors.New("unit log is empty")
+	}
+	for i, e := range p.Entries {
+		if e == nil {
+			return fmt.Errorf("log entry %d is nil", i)
+		}
+		// only the first entry of the round may be not created by a transaction
+		if i > 0 && e.TransactionRecordHash == nil {
+			return fmt.Errorf("log entry %d transaction record hash is nil", i)
+		}
+	}
+	if p.StateTreeCert == nil {
+		return errors.New("state tree cert is nil")
+	}
+	if p.UnicityCertificate == nil {
+		return errors.New("unicity certificate is nil")
+	}
+	return nil
+}
+
+func (p *UnitLogProof) GetVersion() ABVersion {
+	if p != nil && p.Version > 0 {
+		return p.Version
+	}
+	return 1
+}
+
+func (p *UnitLogProof) MarshalCBOR() ([]byte, error) {
+	type alias UnitLogProof
+	if p.Version == 0 {
+		p.Version = p.GetVersion()
+	}
+	return Cbor.MarshalTaggedValue(UnitLogProofTag, (*alias)(p))
+}
+
+func (p *UnitLogProof) UnmarshalCBOR(data []byte) error {
+	type alias UnitLogProof
+	if err := Cbor.UnmarshalTaggedValue(UnitLogProofTag, data, (*alias)(p)); err != nil {
+		return err
+	}
+	return EnsureVersion(p, p.Version, 1)
+}
+
+func (h logEntryHash) Hash(crypto.Hash) ([]byte, error) {
+	return h, nil
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	abhash "github.com/alphabill-org/alphabill-go-base/hash"
+	"github.com/alphabill-org/alphabill-go-base/tree/mt"
+	"github.com/alphabill-org/alphabill-go-base/util"
+)
+
+func TestUnitLogProof(t *testing.T) {
+	newState := func(v uint64) *UnitState {
+		data, err := Cbor.Marshal([]uint64{v})
+		require.NoError(t, err)
+		return &UnitState{Data: data}
+	}
+	states := []*UnitState{newState(1), newState(2), newState(3)}
+
+	newProof := func(t *testing.T) *UnitLogProof {
+		proof := &UnitLogProof{
+			Version:        1,
+			UnitID:         unitID,
+			UnitValue:      3,
+			UnitLedgerHash: make([]byte, 32),
+			Entries: []*UnitLogEntry{
+				{UnitStateHash: doHash(t, states[0])},
+				{TransactionRecordHash: []byte{1}, UnitStateHash: doHash(t, states[1])},
+				{TransactionRecordHash: []byte{2}, UnitStateHash: doHash(t, states[2])},
+			},
+			StateTreeCert: &StateTreeCert{LeftSummaryHash: []byte{9}, LeftSummaryValue: 10},
+		}
+		rootHash, summary, err := proof.CalculateStateTreeOutput(crypto.SHA256)
+		require.NoError(t, err)
+		require.EqualValues(t, 13, summary)
+		proof.UnicityCertificate, err = (&UnicityCertificate{
+			Version:     1,
+			InputRecord: &InputRecord{Version: 1, Hash: rootHash, SummaryValue: util.Uint64ToBytes(summary)},
+		}).MarshalCBOR()
+		require.NoError(t, err)
+		return proof
+	}
+
+	t.Run("success", func(t *testing.T) {
+		proof := newProof(t)
+		require.NoError(t, proof.Verify(crypto.SHA256, states, &alwaysValid{}, nil))
+
+		data, err := Cbor.Marshal(proof)
+		require.NoError(t, err)
+		proof2 := &UnitLogProof{}
+		require.NoError(t, Cbor.Unmarshal(data, proof2))
+		require.NoError(t, proof2.Verify(crypto.SHA256, states, &alwaysValid{}, nil))
+	})
+
+	t.Run("latest entry matches unit state proof", func(t *testing.T) {
+		// unit state proof of the latest entry has the same state tree output
+		proof := newProof(t)
+		z0, err := UnitLedgerHash(crypto.SHA256, proof.UnitLedgerHash, nil, proof.Entries[0].UnitStateHash)
+		require.NoError(t, err)
+		z1, err := UnitLedgerHash(crypto.SHA256, z0, proof.Entries[1].TransactionRecordHash, proof.Entries[1].UnitStateHash)
+		require.NoError(t, err)
+		z01, err := abhash.HashValues(crypto.SHA256, z0, z1)
+		require.NoError(t, err)
+		usp := &UnitStateProof{
+			Version:        1,
+			UnitID:         unitID,
+			UnitValue:      3,
+			UnitLedgerHash: z1,
+			UnitTreeCert: &UnitTreeCert{
+				TransactionRecordHash: proof.Entries[2].TransactionRecordHash,
+				UnitStateHash:         proof.Entries[2].UnitStateHash,
+				Path:                  []*mt.PathItem{{DirectionLeft: false, Hash: z01}},
+			},
+			StateTreeCert:      proof.StateTreeCert,
+			UnicityCertificate: proof.UnicityCertificate,
+		}
+		require.NoError(t, usp.Verify(crypto.SHA256, states[2], &alwaysValid{}, nil))
+	})
+
+	t.Run("invalid states", func(t *testing.T) {
+		proof := newProof(t)
+		require.EqualError(t, proof.Verify(crypto.SHA256, states[:2], &alwaysValid{}, nil), "expected 3 unit states, got 2")
+		require.EqualError(t, proof.Verify(crypto.SHA256, []*UnitState{states[0], nil, states[2]}, &alwaysValid{}, nil), "unit state 1 is nil")
+		require.EqualError(t, proof.Verify(crypto.SHA256, []*UnitState{states[0], states[2], states[1]}, &alwaysValid{}, nil), "unit state 1 hash does not match unit state hash of the log entry")
+	})
+
+	t.Run("invalid log", func(t *testing.T) {
+		// the entries are in the wrong order
+		proof := newProof(t)
+		proof.Entries[1], proof.Entries[2] = proof.Entries[2], proof.Entries[1]
+		require.ErrorContains(t, proof.Verify(crypto.SHA256, []*UnitState{states[0], states[2], states[1]}, &alwaysValid{}, nil), "invalid state root hash")
+
+		// the entry is missing
+		proof = newProof(t)
+		proof.Entries = proof.Entries[1:]
+		require.ErrorContains(t, proof.Verify(crypto.SHA256, states[1:], &alwaysValid{}, nil), "invalid state root hash")
+
+		// wrong summary value
+		proof = newProof(t)
+		proof.UnitValue = 4
+		require.ErrorContains(t, proof.Verify(crypto.SHA256, states, &alwaysValid{}, nil), "invalid summary value")
+	})
+
+	t.Run("invalid UC", func(t *testing.T) {
+		require.EqualError(t, newProof(t).Verify(crypto.SHA256, states, &alwaysInvalid{}, nil), "invalid unicity certificate: invalid uc")
+		require.EqualError(t, newProof(t).Verify(crypto.SHA256, states, nil, nil), "unicity certificate validator is nil")
+	})
+
+	t.Run("IsValid", func(t *testing.T) {
+		var proof *UnitLogProof
+		require.ErrorIs(t, proof.IsValid(), ErrUnitLogProofIsNil)
+
+		proof = newProof(t)
+		proof.Version = 2
+		require.ErrorContains(t, proof.IsValid(), "invalid version")
+
+		proof = newProof(t)
+		proof.UnitID = nil
+		require.EqualError(t, proof.IsValid(), "unit ID is unassigned")
+
+		proof = newProof(t)
+		proof.Entries = nil
+		require.EqualError(t, proof.IsValid(), "unit log is empty")
+
+		proof = newProof(t)
+		proof.Entries[1] = nil
+		require.EqualError(t, proof.IsValid(), "log entry 1 is nil")
+
+		proof = newProof(t)
+		proof.Entries[2].TransactionRecordHash = nil
+		require.EqualError(t, proof.IsValid(), "log entry 2 transaction record hash is nil")
+
+		proof = newProof(t)
+		proof.StateTreeCert = nil
+		require.EqualError(t, proof.IsValid(), "state tree cert is nil")
+
+		proof = newProof(t)
+		proof.UnicityCertificate = nil
+		require.EqualError(t, proof.IsValid(), "unicity certificate is nil")
+		require.EqualError(t, proof.Verify(crypto.SHA256, states, &alwaysValid{}, nil), "invalid unit log proof: unicity certificate is nil")
+	})
+}
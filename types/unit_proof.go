@@ -78,6 +78,11 @@ func NewUnitState(unitData UnitData, deletionRound uint64, stateLockTx RawCBOR)
 	}, nil
 }
 
+// GetUC returns the unicity certificate of the proof.
+func (u *UnitStateProof) GetUC() (*UnicityCertificate, error) {
+	return u.getUCv1()
+}
+
 func (u *UnitStateProof) getUCv1() (*UnicityCertificate, error) {
 	if u == nil {
 		return nil, errors.New("unit state proof is nil")
@@ -93,7 +98,12 @@ func (u *UnitStateProof) getUCv1() (*UnicityCertificate, error) {
 	return uc, nil
 }
 
-func (u *UnitStateProof) Verify(algorithm crypto.Hash, unitState *UnitState, ucv UnicityCertificateValidator, shardConfHash []byte) error {
+/*
+Verify checks that the "unitState" is the state of the unit certified by the UC
+of the proof. The UC is validated by the "ucv", use WithAcceptedNetworks option
+to bind the proof to the networks independently of the validator.
+*/
+func (u *UnitStateProof) Verify(algorithm crypto.Hash, unitState *UnitState, ucv UnicityCertificateValidator, shardConfHash []byte, opts ...VerifyOption) error {
 	if err := u.IsValid(); err != nil {
 		return fmt.Errorf("invalid unit state proof: %w", err)
 	}
@@ -108,6 +118,14 @@ func (u *UnitStateProof) Verify(algorithm crypto.Hash, unitState *UnitState, ucv
 	if err := ucv.Validate(uc, shardConfHash); err != nil {
 		return fmt.Errorf("invalid unicity certificate: %w", err)
 	}
+	if o := newVerifyOptions(opts); len(o.networks) != 0 {
+		if uc.UnicitySeal == nil {
+			return ErrUnicitySealIsNil
+		}
+		if err := o.checkNetwork(uc.UnicitySeal.NetworkID, nil); err != nil {
+			return err
+		}
+	}
 
 	unitStateHash, err := unitState.Hash(algorithm)
 	if err != nil {
@@ -121,7 +139,14 @@ func (u *UnitStateProof) Verify(algorithm crypto.Hash, unitState *UnitState, ucv
 	if err != nil {
 		return fmt.Errorf("failed to calculate state tree output: %w", err)
 	}
-	ir := uc.InputRecord
+	return checkStateTreeOutput(uc.InputRecord, stateRootHash, summary)
+}
+
+// checkStateTreeOutput checks that the state tree output is certified by the input record.
+func checkStateTreeOutput(ir *InputRecord, stateRootHash []byte, summary uint64) error {
+	if ir == nil {
+		return ErrInputRecordIsNil
+	}
 	if !bytes.Equal(util.Uint64ToBytes(summary), ir.SummaryValue) {
 		return fmt.Errorf("invalid summary value: expected %X, got %X", ir.SummaryValue, util.Uint64ToBytes(summary))
 	}
@@ -132,19 +157,7 @@ func (u *UnitStateProof) Verify(algorithm crypto.Hash, unitState *UnitState, ucv
 }
 
 func (u *UnitStateProof) CalculateStateTreeOutput(algorithm crypto.Hash) ([]byte, uint64, error) {
-	var z []byte
-	var err error
-	if u.UnitTreeCert.TransactionRecordHash == nil {
-		z, err = abhash.HashValues(algorithm,
-			u.UnitLedgerHash,
-			u.UnitTreeCert.UnitStateHash,
-		)
-	} else {
-		z, err = abhash.HashValues(algorithm, u.UnitLedgerHash, u.UnitTreeCert.TransactionRecordHash)
-		if err == nil {
-			z, err = abhash.HashValues(algorithm, z, u.UnitTreeCert.UnitStateHash)
-		}
-	}
+	z, err := UnitLedgerHash(algorithm, u.UnitLedgerHash, u.UnitTreeCert.TransactionRecordHash, u.UnitTreeCert.UnitStateHash)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to calculate input hash: %w", err)
 	}
@@ -153,9 +166,28 @@ func (u *UnitStateProof) CalculateStateTreeOutput(algorithm crypto.Hash) ([]byte
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to calculate log root: %w", err)
 	}
-	id := u.UnitID
-	sc := u.StateTreeCert
-	v := u.UnitValue + sc.LeftSummaryValue + sc.RightSummaryValue
+	return u.StateTreeCert.calculateOutput(algorithm, u.UnitID, logRoot, u.UnitValue)
+}
+
+/*
+UnitLedgerHash returns the head of the unit's ledger after appending the entry
+(txrHash, stateHash) to the ledger with head "prevHash". The transaction record
+hash is nil when the state was not created by a transaction.
+*/
+func UnitLedgerHash(algorithm crypto.Hash, prevHash, txrHash, stateHash []byte) ([]byte, error) {
+	if txrHash == nil {
+		return abhash.HashValues(algorithm, prevHash, stateHash)
+	}
+	z, err := abhash.HashValues(algorithm, prevHash, txrHash)
+	if err != nil {
+		return nil, err
+	}
+	return abhash.HashValues(algorithm, z, stateHash)
+}
+
+// calculateOutput returns the state root hash and summary value of the state tree.
+func (sc *StateTreeCert) calculateOutput(algorithm crypto.Hash, id UnitID, logRoot []byte, unitValue uint64) ([]byte, uint64, error) {
+	v := unitValue + sc.LeftSummaryValue + sc.RightSummaryValue
 	h, err := computeHash(algorithm, id, logRoot, v, sc.LeftSummaryHash, sc.LeftSummaryValue, sc.RightSummaryHash, sc.RightSummaryValue)
 	if err != nil {
 		return nil, 0, err
@@ -147,6 +147,14 @@ func TestVerifyUnitStateProof(t *testing.T) {
 		proof.UnicityCertificate, err = uc.MarshalCBOR()
 		require.NoError(t, err)
 		require.NoError(t, proof.Verify(crypto.SHA256, unitState, &alwaysValid{}, nil), "unexpected error")
+
+		// the validator doesn't bind the proof to the network
+		require.ErrorIs(t, proof.Verify(crypto.SHA256, unitState, &alwaysValid{}, nil, WithAcceptedNetworks(NetworkMainNet)), ErrUnicitySealIsNil)
+		uc.UnicitySeal = &UnicitySeal{Version: 1, NetworkID: NetworkTestNet}
+		proof.UnicityCertificate, err = uc.MarshalCBOR()
+		require.NoError(t, err)
+		require.ErrorIs(t, proof.Verify(crypto.SHA256, unitState, &alwaysValid{}, nil, WithAcceptedNetworks(NetworkMainNet)), ErrNetworkMismatch)
+		require.NoError(t, proof.Verify(crypto.SHA256, unitState, &alwaysValid{}, nil, WithAcceptedNetworks(NetworkMainNet, NetworkTestNet)))
 	})
 }
 
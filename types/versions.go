@@ -35,6 +35,15 @@ const (
 	TransactionOrderTag
 	RootPartitionBlockDataTag
 	RootPartitionRoundInfoTag
+	EquivocationProofTag
+	BlockCertificationRequestTag
+	TechnicalRecordTag
+	TxMultiProofTag
+	TxErrorTag
+	ProofBundleTag
+	TxUnitStateProofTag
+	UnitLogProofTag
+	UnitAbsenceProofTag
 )
 
 func ErrInvalidVersion(s Versioned) error {